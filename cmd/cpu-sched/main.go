@@ -1,43 +1,329 @@
 package main
 
 import (
+	"encoding/csv"
+	"flag"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	cpu_schedule "ostep-go/cpu-schedule"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 )
 
+// Times on the command line and in workload files are in seconds, like
+// OSTEP's scheduler.py.
+type options struct {
+	policy   string
+	quantum  float64
+	seed     int64
+	queues   int
+	tieBreak string
+	aging    float64
+	nonPre   bool
+	starve   float64
+	jobs     int
+	maxLen   int
+	jobList  string
+	file     string
+	switchCs float64
+	preempt  bool
+	rename   bool
+	trace    string
+	shares   float64
+	compare  bool
+	csv      bool
+	solve    bool
+	trials   int
+	dist     string
+	mean     float64
+	arrival  float64
+
+	schedulerOptions cpu_schedule.SchedulerOptions
+}
+
 func main() {
-	fmt.Println("cpu-sched")
-
-	s := cpu_schedule.NewSimulator()
-	jobs := []cpu_schedule.Job{
-		{Name: "Job 0", Length: 8000},
-		{Name: "Job 1", Length: 7000},
-		{Name: "Job 2", Length: 1000},
-		{Name: "Job 3", Length: 4000},
-		{Name: "Job 4", Length: 5000},
-		{Name: "Job 5", Length: 6000},
-	}
-	//jobs := []cpu_schedule.Job{
-	//	{Name: "Job 0", Length: 1000},
-	//	{Name: "Job 1", Length: 1000},
-	//	{Name: "Job 2", Length: 1000},
-	//	{Name: "Job 3", Length: 1000},
-	//	{Name: "Job 4", Length: 1000},
-	//	{Name: "Job 5", Length: 1000},
-	//}
-
-	//fmt.Println("====FIFO====")
-	//fifo := cpu_schedule.NewFIFOScheduler()
-	//s.Run(fifo, jobs)
-	//fmt.Println()
-
-	//fmt.Println("====SJF====")
-	//sjf := cpu_schedule.NewSJFScheduler()
-	//s.Run(sjf, jobs)
-	//fmt.Println()
-
-	fmt.Println("====Round Robin====")
-	rr := cpu_schedule.NewRoundRobinScheduler()
-	s.Run(rr, jobs)
-	fmt.Println("cpu-sched done")
+	opts := options{}
+	flag.StringVar(&opts.policy, "p", "FIFO", "scheduling policy, one of "+strings.Join(cpu_schedule.SchedulerNames(), ", "))
+	flag.Float64Var(&opts.quantum, "q", 1, "length of time slice in seconds")
+	flag.Int64Var(&opts.seed, "s", 0, "random seed for the generated workload and the lottery")
+	flag.IntVar(&opts.queues, "n", 3, "number of MLFQ queues")
+	flag.StringVar(&opts.tieBreak, "tiebreak", "insertion", "how equally ranked jobs are picked: insertion, arrival or name")
+	flag.Float64Var(&opts.aging, "aging", 0, "raise a waiting job's priority by one every this many seconds, 0 to disable")
+	flag.BoolVar(&opts.nonPre, "nonpreemptive", false, "let a PRIORITY job keep the CPU until it finishes or blocks")
+	flag.Float64Var(&opts.starve, "starve", 0, "flag jobs that once waited longer than this many seconds as starved")
+	flag.IntVar(&opts.jobs, "j", 3, "number of jobs to generate")
+	flag.IntVar(&opts.maxLen, "m", 10, "max length of a generated job in seconds")
+	flag.StringVar(&opts.jobList, "l", "", "comma separated list of job lengths in seconds instead of random jobs")
+	flag.StringVar(&opts.file, "f", "", "workload file with one 'name arrival length [tickets [priority]]' per line, - for stdin")
+	flag.Float64Var(&opts.switchCs, "cs", 0, "context switch cost in seconds")
+	flag.BoolVar(&opts.preempt, "preempt", false, "reschedule as soon as a job arrives")
+	flag.BoolVar(&opts.rename, "rename", false, "rename jobs with duplicate names instead of failing")
+	flag.Float64Var(&opts.shares, "shares", 0, "also print each job's CPU share in windows of this many seconds and the unfairness, 0 to disable")
+	flag.StringVar(&opts.trace, "trace", "", "also print the schedule as gantt, csv or chrome")
+	flag.BoolVar(&opts.compare, "compare", false, "run every policy on the same jobs and print a side-by-side table")
+	flag.BoolVar(&opts.csv, "csv", false, "print the -compare table as CSV")
+	flag.BoolVar(&opts.solve, "solve", false, "read scheduler.py output from stdin and print its -c solution")
+	flag.IntVar(&opts.trials, "trials", 0, "run every policy against this many random workloads of -j jobs and print statistics")
+	flag.StringVar(&opts.dist, "dist", "exp", "job length distribution for -trials: exp, pareto or uniform")
+	flag.Float64Var(&opts.mean, "mean", 5, "mean job length in seconds for -trials")
+	flag.Float64Var(&opts.arrival, "arrival", 0, "mean time between Poisson arrivals in seconds for -trials, 0 for all at once")
+	flag.Parse()
+
+	tieBreak, err := cpu_schedule.ParseTieBreak(opts.tieBreak)
+	if err == nil {
+		err = validate(opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.schedulerOptions = cpu_schedule.SchedulerOptions{
+		Quantum:       toMs(opts.quantum),
+		Queues:        opts.queues,
+		Seed:          opts.seed,
+		TieBreak:      tieBreak,
+		AgingInterval: toMs(opts.aging),
+		NonPreemptive: opts.nonPre,
+	}
+
+	if opts.solve {
+		problem, err := parseProblem(os.Stdin)
+		if err != nil {
+			fail(err)
+		}
+		if err := solve(os.Stdout, problem, opts.quantum); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if opts.trials > 0 {
+		if err := experiment(os.Stdout, opts); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	jobs, err := loadJobs(opts)
+	if err != nil {
+		fail(err)
+	}
+	if opts.rename {
+		jobs = cpu_schedule.RenameDuplicates(jobs)
+	}
+
+	if opts.compare {
+		if err := compare(os.Stdout, opts, jobs); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	scheduler, err := cpu_schedule.MakeScheduler(opts.policy, opts.schedulerOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	result, err := newSimulator(opts).Run(scheduler, jobs)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("Policy %s\n\n", strings.ToUpper(opts.policy))
+	reporter := cpu_schedule.NewTextReporter(os.Stdout)
+	reporter.Report(result)
+	if opts.shares > 0 {
+		fmt.Println()
+		reporter.ReportShares(result, toMs(opts.shares))
+	}
+
+	if opts.trace != "" {
+		fmt.Println()
+		if err := writeTrace(os.Stdout, opts.trace, toMs(opts.quantum), result); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// fail prints err, such as a workload ValidateJobs rejects, and exits with a
+// non-zero status.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// validate rejects the numeric flags that would make the simulation hang or
+// crash.
+func validate(opts options) error {
+	for _, seconds := range []struct {
+		flag  string
+		value float64
+	}{{"-q", opts.quantum}, {"-cs", opts.switchCs}, {"-aging", opts.aging}, {"-starve", opts.starve}, {"-shares", opts.shares}} {
+		if math.IsNaN(seconds.value) || math.Abs(seconds.value*1000) >= math.MaxInt64 {
+			return fmt.Errorf("%s is out of range, got %v", seconds.flag, seconds.value)
+		}
+	}
+	switch {
+	case toMs(opts.quantum) <= 0:
+		return fmt.Errorf("-q must be positive, got %v", opts.quantum)
+	case opts.jobs < 0:
+		return fmt.Errorf("-j must not be negative, got %d", opts.jobs)
+	case opts.maxLen <= 0:
+		return fmt.Errorf("-m must be positive, got %d", opts.maxLen)
+	case opts.switchCs < 0:
+		return fmt.Errorf("-cs must not be negative, got %v", opts.switchCs)
+	case opts.shares < 0:
+		return fmt.Errorf("-shares must not be negative, got %v", opts.shares)
+	}
+	return nil
+}
+
+func simulatorOptions(opts options) []cpu_schedule.SimulatorOption {
+	simOpts := []cpu_schedule.SimulatorOption{
+		cpu_schedule.WithQuantum(toMs(opts.quantum)),
+		cpu_schedule.WithContextSwitchCost(toMs(opts.switchCs)),
+	}
+	if opts.preempt {
+		simOpts = append(simOpts, cpu_schedule.WithPreemptOnArrival())
+	}
+	if opts.starve > 0 {
+		simOpts = append(simOpts, cpu_schedule.WithStarvationThreshold(toMs(opts.starve)))
+	}
+	return simOpts
+}
+
+func newSimulator(opts options) *cpu_schedule.Simulator {
+	return cpu_schedule.NewSimulator(simulatorOptions(opts)...)
+}
+
+// compare runs every registered policy against the same jobs and prints the
+// average response, turnaround and wait of each, in seconds.
+func compare(w io.Writer, opts options, jobs []cpu_schedule.Job) error {
+	rows := [][]string{{"Policy", "Response", "Turnaround", "Wait"}}
+	for _, policy := range cpu_schedule.SchedulerNames() {
+		scheduler, err := cpu_schedule.MakeScheduler(policy, opts.schedulerOptions)
+		if err != nil {
+			return err
+		}
+
+		result, err := newSimulator(opts).Run(scheduler, jobs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", policy, err)
+		}
+		rows = append(rows, []string{
+			policy,
+			formatSeconds(result.AverageResponse),
+			formatSeconds(result.AverageTurnaround),
+			formatSeconds(result.AverageWait),
+		})
+	}
+
+	if opts.csv {
+		return csv.NewWriter(w).WriteAll(rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	return tw.Flush()
+}
+
+// experiment runs every registered policy against opts.trials random
+// workloads and prints the mean, percentiles and 95% confidence interval of
+// response and turnaround, in seconds.
+func experiment(w io.Writer, opts options) error {
+	mean := float64(toMs(opts.mean))
+	config := cpu_schedule.ExperimentConfig{
+		Trials:           opts.trials,
+		Jobs:             opts.jobs,
+		Seed:             opts.seed,
+		Policies:         cpu_schedule.SchedulerNames(),
+		SchedulerOptions: opts.schedulerOptions,
+		SimulatorOptions: simulatorOptions(opts),
+	}
+	switch opts.dist {
+	case "exp":
+		config.Lengths = cpu_schedule.Exponential{Mean: mean}
+	case "pareto":
+		// alpha 1.5 gives a heavy tail with a finite mean
+		config.Lengths = cpu_schedule.Pareto{Min: mean / 3, Alpha: 1.5}
+	case "uniform":
+		config.Lengths = cpu_schedule.Uniform{Min: 0, Max: 2 * mean}
+	default:
+		return fmt.Errorf("unknown distribution %s", opts.dist)
+	}
+	if opts.arrival > 0 {
+		config.InterArrivals = cpu_schedule.Exponential{Mean: float64(toMs(opts.arrival))}
+	}
+
+	stats, err := cpu_schedule.RunExperiment(config)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Policy\tMetric\tMean\t95% CI\tp50\tp95\tp99\t")
+	for _, s := range stats {
+		for _, metric := range []struct {
+			name  string
+			stats cpu_schedule.Stats
+		}{{"Response", s.Response}, {"Turnaround", s.Turnaround}} {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s-%s\t%s\t%s\t%s\t\n", s.Policy, metric.name,
+				formatSeconds(metric.stats.Mean),
+				formatSeconds(metric.stats.CILow), formatSeconds(metric.stats.CIHigh),
+				formatSeconds(metric.stats.P50),
+				formatSeconds(metric.stats.P95),
+				formatSeconds(metric.stats.P99),
+			)
+		}
+	}
+	return tw.Flush()
+}
+
+func loadJobs(opts options) ([]cpu_schedule.Job, error) {
+	switch {
+	case opts.file == "-":
+		return cpu_schedule.ParseWorkload(os.Stdin)
+	case opts.file != "":
+		f, err := os.Open(opts.file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return cpu_schedule.ParseWorkload(f)
+	case opts.jobList != "":
+		return cpu_schedule.ParseJobList(opts.jobList)
+	default:
+		return cpu_schedule.GenerateWorkload(opts.seed, opts.jobs, opts.maxLen)
+	}
+}
+
+// writeTrace prints the trace of result in format. Every column of a Gantt
+// chart is one -q time slice.
+func writeTrace(w io.Writer, format string, unit int64, result cpu_schedule.SimulationResult) error {
+	switch format {
+	case "gantt":
+		return cpu_schedule.RenderGantt(w, result, unit)
+	case "csv":
+		return cpu_schedule.WriteTraceCSV(w, result)
+	case "chrome":
+		return cpu_schedule.WriteChromeTrace(w, result)
+	default:
+		return fmt.Errorf("unknown trace format %s", format)
+	}
+}
+
+func toMs(seconds float64) int64 {
+	return int64(seconds * 1000)
+}
+
+func formatSeconds(ms float64) string {
+	return strconv.FormatFloat(ms/1000, 'f', 2, 64)
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	cpu_schedule "ostep-go/cpu-schedule"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem is a question printed by OSTEP's scheduler.py, e.g.
+//
+//	ARG policy SJF
+//	ARG jobs 3
+//	ARG maxlen 10
+//	ARG seed 0
+//
+//	Here is the job list, with the run time of each job:
+//	  Job 0 ( length = 9 )
+//	  Job 1 ( length = 8 )
+//	  Job 2 ( length = 5 )
+type Problem struct {
+	policy string
+	// quantum is in seconds; scheduler.py does not print it, so it is only
+	// set when the input has an "ARG quantum" line.
+	quantum float64
+	// lengths are in seconds, indexed by job number
+	lengths []float64
+}
+
+func parseProblem(reader io.Reader) (Problem, error) {
+	scanner := bufio.NewScanner(reader)
+	problem := Problem{}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "ARG policy") {
+			problem.policy = strings.TrimSpace(strings.TrimPrefix(line, "ARG policy"))
+		} else if strings.HasPrefix(line, "ARG quantum") {
+			quantum, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, "ARG quantum")), 64)
+			if err != nil {
+				return problem, err
+			}
+			problem.quantum = quantum
+		} else if strings.HasPrefix(line, "Here is the job list") {
+			for scanner.Scan() {
+				line := scanner.Text()
+				if !strings.HasPrefix(line, "  Job") {
+					break
+				}
+
+				// "  Job 0 ( length = 9 )"
+				tokens := strings.Fields(line)
+				if len(tokens) != 7 {
+					return problem, fmt.Errorf("invalid job line %q", line)
+				}
+				length, err := strconv.ParseFloat(tokens[5], 64)
+				if err != nil {
+					return problem, err
+				}
+				problem.lengths = append(problem.lengths, length)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return problem, err
+	}
+	if problem.policy == "" {
+		return problem, fmt.Errorf("no ARG policy line found")
+	}
+
+	return problem, nil
+}
+
+// solve prints the answer the same way scheduler.py -c does.
+func solve(w io.Writer, problem Problem, quantum float64) error {
+	if problem.quantum > 0 {
+		quantum = problem.quantum
+	}
+
+	jobs := make([]cpu_schedule.Job, len(problem.lengths))
+	total := int64(0)
+	for idx, length := range problem.lengths {
+		jobs[idx] = cpu_schedule.Job{Name: strconv.Itoa(idx), Length: uint64(toMs(length))}
+		total += toMs(length)
+	}
+
+	policy := strings.ToUpper(problem.policy)
+	sliced := false
+	switch policy {
+	case "FIFO", "SJF":
+		// both run every job to completion
+		quantum = float64(total)/1000 + 1
+	case "RR":
+		sliced = true
+	default:
+		return fmt.Errorf("scheduler.py has no policy %s", problem.policy)
+	}
+
+	scheduler, err := cpu_schedule.MakeScheduler(policy, cpu_schedule.SchedulerOptions{})
+	if err != nil {
+		return err
+	}
+	result, err := cpu_schedule.NewSimulator(cpu_schedule.WithQuantum(toMs(quantum))).Run(scheduler, jobs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "** Solutions **\n\n")
+	fmt.Fprintln(w, "Execution trace:")
+	completions := make(map[string]int64)
+	for _, job := range result.Jobs {
+		completions[job.Name] = job.Completion
+	}
+	for _, run := range result.Runs {
+		num, err := strconv.Atoi(run.Job)
+		if err != nil {
+			return err
+		}
+		start := float64(run.Start) / 1000
+		ran := float64(run.End-run.Start) / 1000
+		done := fmt.Sprintf(" ( DONE at %.2f )", float64(run.End)/1000)
+		if completions[run.Job] != run.End {
+			done = ""
+		}
+		if sliced {
+			fmt.Fprintf(w, "  [ time %3d ] Run job %3d for %.2f secs%s\n", int64(start), num, ran, done)
+		} else {
+			fmt.Fprintf(w, "  [ time %3d ] Run job %d for %.2f secs%s\n", int64(start), num, ran, done)
+		}
+	}
+
+	stats := make([]cpu_schedule.JobResult, len(result.Jobs))
+	copy(stats, result.Jobs)
+	if !sliced {
+		// scheduler.py lists the jobs in the order they ran
+		sort.SliceStable(stats, func(i, j int) bool {
+			return stats[i].FirstRun < stats[j].FirstRun
+		})
+	}
+
+	fmt.Fprintln(w, "\nFinal statistics:")
+	for _, job := range stats {
+		num, err := strconv.Atoi(job.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  Job %3d -- Response: %3.2f  Turnaround %3.2f  Wait %3.2f\n", num,
+			float64(job.Response)/1000, float64(job.Turnaround)/1000, float64(job.Wait)/1000)
+	}
+	fmt.Fprintf(w, "\n  Average -- Response: %3.2f  Turnaround %3.2f  Wait %3.2f\n\n",
+		result.AverageResponse/1000, result.AverageTurnaround/1000, result.AverageWait/1000)
+
+	return nil
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func foo() {
-	best, err := vm_freespace.MakeFreeSpaceStrategy("BEST", 1000, 100)
+	best, err := vm_freespace.MakeFreeSpaceStrategy("BEST", 1000, 100, vm_freespace.Options{})
 	if err != nil {
 		panic(err)
 	}
@@ -32,28 +32,50 @@ func main() {
 		panic(err)
 	}
 
-	strategy, err := vm_freespace.MakeFreeSpaceStrategy(input.StrategyName, input.BaseAddr, input.Space)
+	strategy, err := vm_freespace.MakeFreeSpaceStrategy(input.StrategyName, input.BaseAddr, input.Space, input.Options)
+	if err != nil {
+		panic(err)
+	}
+	internal := 0
 	for _, op := range input.Operations {
 		switch op := op.(type) {
 		case AllocOperation:
+			// a failed allocation returns -1, like malloc.py
 			res := strategy.Alloc(vm_freespace.Pointer(op.PointerIndex), op.Size)
-			if res.Err != nil {
-				panic(res.Err)
-			}
 			//			ptr[4] = Alloc(2) returned 1000 (searched 4 elements)
 			//Free List [ Size 4 ]: [ addr:1002 sz:1 ][ addr:1003 sz:5 ][ addr:1008 sz:8 ][ addr:1016 sz:84 ]
-			fmt.Printf("ptr[%d] = Alloc(%d) return %d (searched %d elements)\n", op.PointerIndex, op.Size, res.Addr, res.Visited)
+			fmt.Printf("ptr[%d] = Alloc(%d) returned %d (searched %d elements)\n", op.PointerIndex, op.Size, res.Addr, res.Visited)
+			if res.Internal > 0 {
+				fmt.Printf("Internal fragmentation: %d\n", res.Internal)
+				internal += res.Internal
+			}
 			fmt.Printf("Free List [ Size %d ]: %s\n", strategy.FreeList().Size(), strategy.FreeList().String())
 			fmt.Println()
 		case FreeOperation:
-			strategy.Free(vm_freespace.Pointer(op.PointerIndex))
+			// freeing a pointer that is not allocated returns -1, like
+			// malloc.py
+			returned := 0
+			if err := strategy.Free(vm_freespace.Pointer(op.PointerIndex)); err != nil {
+				returned = -1
+			}
 			fmt.Printf("Free(ptr[%d])\n", op.PointerIndex)
-			fmt.Printf("returned %d\n", 0)
+			fmt.Printf("returned %d\n", returned)
 			fmt.Printf("Free List [ Size %d ]: %s\n", strategy.FreeList().Size(), strategy.FreeList().String())
 			fmt.Println()
 
 		}
 	}
+	fmt.Println(strategy.FreeList().Fragmentation())
+	if internal > 0 {
+		fmt.Printf("Internal fragmentation: %d\n", internal)
+	}
+	if segregated, ok := strategy.(*vm_freespace.SegregatedStrategy); ok {
+		stats := segregated.Stats()
+		for idx, freeList := range segregated.ClassFreeLists() {
+			fmt.Printf("Class %d List [ Size %d ]: %s\n", stats.Classes[idx].Size, freeList.Size(), freeList.String())
+		}
+		fmt.Print(stats)
+	}
 
 	//
 	//fmt.Println(ops)
@@ -105,6 +127,7 @@ type SimulationInput struct {
 	Space        int
 	BaseAddr     int
 	StrategyName string
+	Options      vm_freespace.Options
 	Operations   []Operation
 }
 
@@ -160,6 +183,30 @@ func parse(reader io.Reader) (SimulationInput, error) {
 			sim.BaseAddr = baseAddr
 		} else if strings.HasPrefix(firstLine, "policy") {
 			sim.StrategyName = firstLine[7:]
+		} else if strings.HasPrefix(firstLine, "headerSize") {
+			headerSize, err := strconv.Atoi(firstLine[11:])
+			if err != nil {
+				return sim, err
+			}
+			sim.Options.HeaderSize = headerSize
+		} else if strings.HasPrefix(firstLine, "alignment") {
+			alignment, err := strconv.Atoi(firstLine[10:])
+			if err != nil {
+				return sim, err
+			}
+			sim.Options.Alignment = alignment
+		} else if strings.HasPrefix(firstLine, "listOrder") {
+			order, err := vm_freespace.ParseListOrder(firstLine[10:])
+			if err != nil {
+				return sim, err
+			}
+			sim.Options.Order = order
+		} else if strings.HasPrefix(firstLine, "coalesce") {
+			coalesce, err := strconv.ParseBool(firstLine[9:])
+			if err != nil {
+				return sim, err
+			}
+			sim.Options.Coalesce = coalesce
 		}
 	}
 	sim.Operations = ops
@@ -0,0 +1,330 @@
+package cpu_schedule
+
+import (
+	"fmt"
+)
+
+// niceToWeight is the kernel's sched_prio_to_weight table, indexed by nice
+// value + 20. Each step is roughly a 10% difference in CPU share.
+var niceToWeight = [40]uint64{
+	/* -20 */ 88761, 71755, 56483, 46273, 36291,
+	/* -15 */ 29154, 23254, 18705, 14949, 11916,
+	/* -10 */ 9548, 7620, 6100, 4904, 3906,
+	/*  -5 */ 3121, 2501, 1991, 1586, 1277,
+	/*   0 */ 1024, 820, 655, 526, 423,
+	/*   5 */ 335, 272, 215, 172, 137,
+	/*  10 */ 110, 87, 70, 56, 45,
+	/*  15 */ 36, 29, 23, 18, 15,
+}
+
+// nice0Weight is the weight of a job with nice value 0.
+const nice0Weight = uint64(1024)
+
+func niceWeight(nice int) uint64 {
+	if nice < -20 {
+		nice = -20
+	}
+	if nice > 19 {
+		nice = 19
+	}
+	return niceToWeight[nice+20]
+}
+
+// CFSConfig configures a CFSScheduler. Times are in ms.
+type CFSConfig struct {
+	// SchedLatency is the period in which every runnable job should run
+	// once. It is split between jobs in proportion to their weight.
+	SchedLatency int64
+	// MinGranularity is the shortest time slice a job is given, however
+	// many jobs are runnable.
+	MinGranularity int64
+}
+
+// DefaultCFSConfig uses the values from OSTEP chapter 9.
+var DefaultCFSConfig = CFSConfig{SchedLatency: 48, MinGranularity: 6}
+
+type cfsEntity struct {
+	job      Job
+	weight   uint64
+	vruntime uint64
+	seq      uint64
+	// queued is whether the entity is runnable and so in the tree
+	queued bool
+}
+
+func (e *cfsEntity) less(other *cfsEntity) bool {
+	if e.vruntime != other.vruntime {
+		return e.vruntime < other.vruntime
+	}
+	return e.seq < other.seq
+}
+
+// CFSScheduler is modelled on the Linux Completely Fair Scheduler. Every job
+// accumulates virtual runtime, scaled by the weight of its Nice value, and the
+// runnable job with the lowest virtual runtime runs next. Runnable jobs are
+// kept in a balanced tree ordered by virtual runtime; jobs that block leave
+// the tree, and when they come back their virtual runtime is raised to the
+// tree's minimum so they cannot monopolize the CPU while catching up.
+type CFSScheduler struct {
+	config      CFSConfig
+	tree        *vruntimeTree
+	entities    map[int]*cfsEntity
+	minVruntime uint64
+	// totalWeight is the weight of the runnable jobs and queued their number
+	totalWeight uint64
+	queued      int
+	seq         uint64
+}
+
+// NewCFSScheduler fails if SchedLatency or MinGranularity is not positive.
+func NewCFSScheduler(config CFSConfig) (*CFSScheduler, error) {
+	if config.SchedLatency <= 0 || config.MinGranularity <= 0 {
+		return nil, fmt.Errorf("invalid CFS latency %d and granularity %d", config.SchedLatency, config.MinGranularity)
+	}
+	return &CFSScheduler{
+		config:   config,
+		tree:     &vruntimeTree{},
+		entities: make(map[int]*cfsEntity),
+	}, nil
+}
+
+func (c *CFSScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	for _, job := range jobs {
+		c.Enqueue(job)
+	}
+	// queued jobs missing from the ready jobs have blocked
+	if c.queued > len(jobs) {
+		ready := make(map[int]bool, len(jobs))
+		for _, job := range jobs {
+			ready[job.ID] = true
+		}
+		for id, entity := range c.entities {
+			if !ready[id] && entity.queued {
+				c.dequeue(entity)
+			}
+		}
+	}
+
+	return c.Next()
+}
+
+// Enqueue makes job runnable. New jobs, and jobs back from I/O, start no
+// lower than the current minimum virtual runtime so they neither starve nor
+// monopolize the CPU.
+func (c *CFSScheduler) Enqueue(job Job) {
+	entity, ok := c.entities[job.ID]
+	if !ok {
+		entity = &cfsEntity{weight: niceWeight(job.Nice)}
+		c.entities[job.ID] = entity
+	}
+	entity.job = job
+	if entity.queued {
+		return
+	}
+	entity.vruntime = max(entity.vruntime, c.minVruntime)
+	entity.queued = true
+	c.totalWeight += entity.weight
+	c.queued++
+	c.insert(entity)
+}
+
+func (c *CFSScheduler) Dequeue(job Job) {
+	if entity, ok := c.entities[job.ID]; ok && entity.queued {
+		c.dequeue(entity)
+	}
+}
+
+func (c *CFSScheduler) dequeue(entity *cfsEntity) {
+	c.tree.delete(entity)
+	entity.queued = false
+	c.totalWeight -= entity.weight
+	c.queued--
+}
+
+func (c *CFSScheduler) Next() (Job, error) {
+	leftmost := c.tree.min()
+	if leftmost == nil {
+		return Job{}, fmt.Errorf("no queued job is ready")
+	}
+	return leftmost.job, nil
+}
+
+// TimeSlice splits SchedLatency between the runnable jobs by weight, but never
+// goes below MinGranularity.
+func (c *CFSScheduler) TimeSlice(job Job) int64 {
+	entity, ok := c.entities[job.ID]
+	if !ok || c.totalWeight == 0 {
+		return c.config.SchedLatency
+	}
+
+	slice := c.config.SchedLatency * int64(entity.weight) / int64(c.totalWeight)
+	if slice < c.config.MinGranularity {
+		slice = c.config.MinGranularity
+	}
+	return slice
+}
+
+func (c *CFSScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	entity, ok := c.entities[job.ID]
+	if !ok {
+		return
+	}
+
+	if finished {
+		if entity.queued {
+			c.dequeue(entity)
+		}
+		delete(c.entities, job.ID)
+	} else {
+		queued := entity.queued
+		if queued {
+			c.tree.delete(entity)
+		}
+		// vruntime is kept in µs so heavy jobs still advance
+		entity.vruntime += uint64(elapsed) * 1000 * nice0Weight / entity.weight
+		if queued {
+			c.insert(entity)
+		}
+	}
+
+	if leftmost := c.tree.min(); leftmost != nil && leftmost.vruntime > c.minVruntime {
+		c.minVruntime = leftmost.vruntime
+	}
+}
+
+func (c *CFSScheduler) insert(entity *cfsEntity) {
+	c.seq++
+	entity.seq = c.seq
+	c.tree.insert(entity)
+}
+
+// vruntimeTree is an AVL tree of entities ordered by virtual runtime.
+type vruntimeTree struct {
+	root *vruntimeNode
+}
+
+type vruntimeNode struct {
+	entity      *cfsEntity
+	left, right *vruntimeNode
+	height      int
+}
+
+func (n *vruntimeNode) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *vruntimeNode) update() {
+	n.height = max(n.left.getHeight(), n.right.getHeight()) + 1
+}
+
+func (n *vruntimeNode) rotateRight() *vruntimeNode {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+func (n *vruntimeNode) rotateLeft() *vruntimeNode {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *vruntimeNode) rebalance() *vruntimeNode {
+	n.update()
+	switch balance := n.left.getHeight() - n.right.getHeight(); {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (t *vruntimeTree) insert(entity *cfsEntity) {
+	t.root = insertNode(t.root, entity)
+}
+
+func insertNode(n *vruntimeNode, entity *cfsEntity) *vruntimeNode {
+	if n == nil {
+		return &vruntimeNode{entity: entity, height: 1}
+	}
+	if entity.less(n.entity) {
+		n.left = insertNode(n.left, entity)
+	} else {
+		n.right = insertNode(n.right, entity)
+	}
+	return n.rebalance()
+}
+
+func (t *vruntimeTree) delete(entity *cfsEntity) {
+	t.root = deleteNode(t.root, entity)
+}
+
+func deleteNode(n *vruntimeNode, entity *cfsEntity) *vruntimeNode {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case n.entity == entity:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		successor := n.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		n.entity = successor.entity
+		n.right = deleteNode(n.right, successor.entity)
+	case entity.less(n.entity):
+		n.left = deleteNode(n.left, entity)
+	default:
+		n.right = deleteNode(n.right, entity)
+	}
+	return n.rebalance()
+}
+
+func (t *vruntimeTree) min() *cfsEntity {
+	n := t.root
+	if n == nil {
+		return nil
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n.entity
+}
+
+// ascend calls fn for every entity in order of virtual runtime until fn
+// returns false.
+func (t *vruntimeTree) ascend(fn func(entity *cfsEntity) bool) {
+	ascendNode(t.root, fn)
+}
+
+func ascendNode(n *vruntimeNode, fn func(entity *cfsEntity) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascendNode(n.left, fn) && fn(n.entity) && ascendNode(n.right, fn)
+}
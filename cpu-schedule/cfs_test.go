@@ -0,0 +1,113 @@
+package cpu_schedule
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func newTestCFS(t *testing.T) *CFSScheduler {
+	t.Helper()
+	scheduler, err := NewCFSScheduler(DefaultCFSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scheduler
+}
+
+func TestCFSSharesByWeight(t *testing.T) {
+	jobs := []Job{
+		{Name: "A", Length: 20000},
+		{Name: "B", Length: 20000, Nice: 5},
+	}
+	for _, scheduler := range []Scheduler{newTestCFS(t), hideQueue(newTestCFS(t))} {
+		result, err := NewSimulator().Run(scheduler, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// nice 0 weighs 1024 and nice 5 weighs 335
+		shares := result.CPUShares(10000)[0].Shares
+		if want := 1024.0 / (1024 + 335); math.Abs(shares["A"]-want) > 0.01 {
+			t.Errorf("A got %.3f of the CPU, want %.3f", shares["A"], want)
+		}
+	}
+}
+
+func TestCFSClampsWakingJobs(t *testing.T) {
+	// A sleeps for 10s after its first 100ms; when it wakes it must share
+	// the CPU with B instead of running until its vruntime catches up
+	jobs := []Job{
+		{Name: "A", Bursts: []Burst{{CPU: 100, IO: 10000}, {CPU: 10000}}},
+		{Name: "B", Length: 30000},
+	}
+	for _, scheduler := range []Scheduler{newTestCFS(t), hideQueue(newTestCFS(t))} {
+		result, err := NewSimulator().Run(scheduler, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		shares := result.CPUShares(5000)[2].Shares
+		if math.Abs(shares["A"]-0.5) > 0.05 {
+			t.Errorf("A got %.2f of the CPU after waking up, want 0.5", shares["A"])
+		}
+	}
+}
+
+func TestVruntimeTreeStaysBalanced(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := &vruntimeTree{}
+	entities := make([]*cfsEntity, 1000)
+	for idx := range entities {
+		entities[idx] = &cfsEntity{vruntime: uint64(random.Intn(100)), seq: uint64(idx)}
+		tree.insert(entities[idx])
+	}
+	random.Shuffle(len(entities), func(i, j int) {
+		entities[i], entities[j] = entities[j], entities[i]
+	})
+	for _, entity := range entities[:500] {
+		tree.delete(entity)
+	}
+
+	var previous *cfsEntity
+	count := 0
+	tree.ascend(func(entity *cfsEntity) bool {
+		if previous != nil && entity.less(previous) {
+			t.Errorf("entity %+v comes after %+v", *entity, *previous)
+		}
+		previous = entity
+		count++
+		return true
+	})
+	if count != 500 {
+		t.Errorf("tree holds %d entities, want 500", count)
+	}
+	if min := tree.min(); min == nil || min != firstEntity(tree) {
+		t.Errorf("min returned %v", min)
+	}
+	checkBalanced(t, tree.root)
+}
+
+func firstEntity(tree *vruntimeTree) *cfsEntity {
+	var first *cfsEntity
+	tree.ascend(func(entity *cfsEntity) bool {
+		first = entity
+		return false
+	})
+	return first
+}
+
+func checkBalanced(t *testing.T, n *vruntimeNode) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	left, right := checkBalanced(t, n.left), checkBalanced(t, n.right)
+	if left-right > 1 || right-left > 1 {
+		t.Errorf("node %+v has subtrees of height %d and %d", *n.entity, left, right)
+	}
+	if height := max(left, right) + 1; n.height != height {
+		t.Errorf("node %+v has height %d, want %d", *n.entity, n.height, height)
+	}
+	return max(left, right) + 1
+}
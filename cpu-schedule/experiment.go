@@ -0,0 +1,211 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Distribution draws random values, in ms, for generated workloads.
+type Distribution interface {
+	Sample(r *rand.Rand) float64
+}
+
+// Exponential has the given mean. Used for inter-arrival times it gives
+// Poisson arrivals.
+type Exponential struct {
+	Mean float64
+}
+
+func (d Exponential) Sample(r *rand.Rand) float64 {
+	return r.ExpFloat64() * d.Mean
+}
+
+// Pareto is heavy-tailed: most values are close to Min but a few are very
+// large. Alpha must be greater than 1 for the mean to be finite.
+type Pareto struct {
+	Min   float64
+	Alpha float64
+}
+
+func (d Pareto) Sample(r *rand.Rand) float64 {
+	// 1 - Float64() is in (0, 1], so the result is finite
+	return d.Min / math.Pow(1-r.Float64(), 1/d.Alpha)
+}
+
+// Uniform is uniform over [Min, Max).
+type Uniform struct {
+	Min float64
+	Max float64
+}
+
+func (d Uniform) Sample(r *rand.Rand) float64 {
+	return d.Min + r.Float64()*(d.Max-d.Min)
+}
+
+// ExperimentConfig configures RunExperiment.
+type ExperimentConfig struct {
+	Trials int
+	// Jobs is the number of jobs in every trial's workload.
+	Jobs int
+	// Seed seeds the first trial; trial i uses Seed+i, so every policy runs
+	// against the same workloads.
+	Seed    int64
+	Lengths Distribution
+	// InterArrivals is the distribution of the time between two arrivals.
+	// When nil all jobs arrive at 0.
+	InterArrivals Distribution
+	// Policies are names registered with RegisterScheduler.
+	Policies         []string
+	SchedulerOptions SchedulerOptions
+	SimulatorOptions []SimulatorOption
+}
+
+// Stats summarizes a metric, in ms, over all trials. Mean is the mean of the
+// per-trial averages and CILow and CIHigh its 95% confidence interval. The
+// percentiles are over every job of every trial.
+type Stats struct {
+	Mean   float64
+	P50    float64
+	P95    float64
+	P99    float64
+	CILow  float64
+	CIHigh float64
+}
+
+type PolicyStats struct {
+	Policy     string
+	Response   Stats
+	Turnaround Stats
+}
+
+// GenerateTrialWorkload draws a workload of config.Jobs jobs from the
+// configured distributions.
+func GenerateTrialWorkload(config ExperimentConfig, seed int64) []Job {
+	r := rand.New(rand.NewSource(seed))
+	jobs := make([]Job, 0, config.Jobs)
+	arrival := float64(0)
+	for idx := 0; idx < config.Jobs; idx++ {
+		if config.InterArrivals != nil && idx > 0 {
+			arrival += config.InterArrivals.Sample(r)
+		}
+		length := math.Max(1, math.Ceil(config.Lengths.Sample(r)))
+		jobs = append(jobs, Job{
+			Name:    fmt.Sprintf("Job %d", idx),
+			Arrival: int64(arrival),
+			Length:  uint64(length),
+		})
+	}
+	return jobs
+}
+
+// RunExperiment runs every policy against config.Trials seeded workloads and
+// summarizes their response and turnaround times.
+func RunExperiment(config ExperimentConfig) ([]PolicyStats, error) {
+	if config.Trials <= 0 || config.Jobs <= 0 {
+		return nil, fmt.Errorf("experiment needs at least one trial and one job")
+	}
+	if config.Lengths == nil {
+		return nil, fmt.Errorf("experiment needs a length distribution")
+	}
+
+	responses := make([]metricSamples, len(config.Policies))
+	turnarounds := make([]metricSamples, len(config.Policies))
+	for trial := 0; trial < config.Trials; trial++ {
+		jobs := GenerateTrialWorkload(config, config.Seed+int64(trial))
+		for idx, policy := range config.Policies {
+			scheduler, err := MakeScheduler(policy, config.SchedulerOptions)
+			if err != nil {
+				return nil, err
+			}
+
+			result, err := NewSimulator(config.SimulatorOptions...).Run(scheduler, jobs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", policy, err)
+			}
+			responses[idx].means = append(responses[idx].means, result.AverageResponse)
+			turnarounds[idx].means = append(turnarounds[idx].means, result.AverageTurnaround)
+			for _, job := range result.Jobs {
+				responses[idx].values = append(responses[idx].values, float64(job.Response))
+				turnarounds[idx].values = append(turnarounds[idx].values, float64(job.Turnaround))
+			}
+		}
+	}
+
+	stats := make([]PolicyStats, 0, len(config.Policies))
+	for idx, policy := range config.Policies {
+		stats = append(stats, PolicyStats{
+			Policy:     policy,
+			Response:   responses[idx].stats(),
+			Turnaround: turnarounds[idx].stats(),
+		})
+	}
+	return stats, nil
+}
+
+type metricSamples struct {
+	// values has one sample per job and means one per trial
+	values []float64
+	means  []float64
+}
+
+func (m metricSamples) stats() Stats {
+	sorted := make([]float64, len(m.values))
+	copy(sorted, m.values)
+	sort.Float64s(sorted)
+
+	n := float64(len(m.means))
+	mean := float64(0)
+	for _, v := range m.means {
+		mean += v
+	}
+	mean /= n
+
+	halfWidth := float64(0)
+	if len(m.means) > 1 {
+		variance := float64(0)
+		for _, v := range m.means {
+			variance += (v - mean) * (v - mean)
+		}
+		variance /= n - 1
+		halfWidth = tCritical95(len(m.means)-1) * math.Sqrt(variance/n)
+	}
+
+	return Stats{
+		Mean:   mean,
+		P50:    percentile(sorted, 50),
+		P95:    percentile(sorted, 95),
+		P99:    percentile(sorted, 99),
+		CILow:  mean - halfWidth,
+		CIHigh: mean + halfWidth,
+	}
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// tTable holds the two-sided 95% critical values of Student's t distribution
+// for 1 to 30 degrees of freedom.
+var tTable = [30]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical95(df int) float64 {
+	if df <= len(tTable) {
+		return tTable[df-1]
+	}
+	// close enough to the normal distribution
+	return 1.96
+}
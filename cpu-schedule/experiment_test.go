@@ -0,0 +1,82 @@
+package cpu_schedule
+
+import (
+	"math"
+	"testing"
+)
+
+func sequence(from, to int) []float64 {
+	values := make([]float64, 0, to-from+1)
+	for v := from; v <= to; v++ {
+		values = append(values, float64(v))
+	}
+	return values
+}
+
+func TestPercentile(t *testing.T) {
+	for _, tt := range []struct {
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{sequence(1, 100), 50, 50},
+		{sequence(1, 100), 95, 95},
+		{sequence(1, 100), 99, 99},
+		{sequence(1, 100), 100, 100},
+		{sequence(1, 100), 0, 1},
+		// nearest rank rounds the rank up: ceil(9.5) = 10
+		{sequence(1, 10), 95, 10},
+		{sequence(1, 10), 50, 5},
+		{[]float64{7}, 99, 7},
+		{nil, 50, 0},
+	} {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("p%v of %d values = %v, want %v", tt.p, len(tt.sorted), got, tt.want)
+		}
+	}
+}
+
+func TestTCritical95(t *testing.T) {
+	for df, want := range map[int]float64{1: 12.706, 4: 2.776, 30: 2.042, 31: 1.96, 1000: 1.96} {
+		if got := tCritical95(df); got != want {
+			t.Errorf("tCritical95(%d) = %v, want %v", df, got, want)
+		}
+	}
+}
+
+func TestMetricStats(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		samples metricSamples
+		want    Stats
+	}{
+		{
+			// s^2 = 2.5, so the half width is 2.776 * sqrt(2.5/5)
+			name:    "five trials",
+			samples: metricSamples{values: sequence(1, 100), means: []float64{5, 1, 4, 2, 3}},
+			want:    Stats{Mean: 3, P50: 50, P95: 95, P99: 99, CILow: 3 - 1.96293, CIHigh: 3 + 1.96293},
+		},
+		{
+			name:    "one trial",
+			samples: metricSamples{values: []float64{30, 10, 20}, means: []float64{20}},
+			want:    Stats{Mean: 20, P50: 20, P95: 30, P99: 30, CILow: 20, CIHigh: 20},
+		},
+	} {
+		got := tt.samples.stats()
+		for _, field := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"Mean", got.Mean, tt.want.Mean},
+			{"P50", got.P50, tt.want.P50},
+			{"P95", got.P95, tt.want.P95},
+			{"P99", got.P99, tt.want.P99},
+			{"CILow", got.CILow, tt.want.CILow},
+			{"CIHigh", got.CIHigh, tt.want.CIHigh},
+		} {
+			if math.Abs(field.got-field.want) > 1e-4 {
+				t.Errorf("%s: %s = %v, want %v", tt.name, field.name, field.got, field.want)
+			}
+		}
+	}
+}
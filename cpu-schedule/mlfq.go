@@ -0,0 +1,223 @@
+package cpu_schedule
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// MLFQConfig configures an MLFQScheduler. Queue 0 has the highest priority.
+type MLFQConfig struct {
+	Queues int
+	// Quantums[i] is the time slice, in ms, of queue i. Queues beyond the end
+	// of the slice reuse its last value; an empty slice means DefaultQuantum.
+	Quantums []int64
+	// Allotments[i] is how long, in ms, a job may run at queue i before it is
+	// moved down a queue. Queues beyond the end of the slice reuse its last
+	// value; an empty slice means one quantum.
+	Allotments []int64
+	// BoostInterval is how often, in ms, all jobs are moved back to the
+	// topmost queue. Zero disables the boost.
+	BoostInterval int64
+	// ResetOnYield restores the original, gameable accounting where a job
+	// that gives up the CPU before its slice ends gets a fresh allotment. By
+	// default the allotment is charged for all of the time a job runs at a
+	// level, no matter how many times it gives up the CPU.
+	ResetOnYield bool
+	// IOToFront puts a job that comes back from I/O at the front of its
+	// queue, like mlfq.py -I. By default it goes to the back.
+	IOToFront bool
+}
+
+type mlfqEntry struct {
+	job   Job
+	level int
+	used  int64
+	// elem is the entry's place in its queue, or nil while the job is
+	// blocked
+	elem *list.Element
+}
+
+// MLFQScheduler implements the Multi-Level Feedback Queue from OSTEP chapter
+// 8. Jobs enter at the topmost queue, run round robin within a queue and are
+// moved down once they use up the allotment of their queue. Only runnable
+// jobs are queued: a job that blocks leaves its queue and rejoins it when its
+// I/O completes.
+type MLFQScheduler struct {
+	config    MLFQConfig
+	queues    []*list.List
+	entries   map[int]*mlfqEntry
+	lastBoost int64
+}
+
+// NewMLFQScheduler fails if a quantum or allotment is not positive or the
+// boost interval is negative.
+func NewMLFQScheduler(config MLFQConfig) (*MLFQScheduler, error) {
+	if config.Queues <= 0 {
+		config.Queues = 1
+	}
+	for _, quantum := range config.Quantums {
+		if quantum <= 0 {
+			return nil, fmt.Errorf("invalid MLFQ quantum %d", quantum)
+		}
+	}
+	for _, allotment := range config.Allotments {
+		if allotment <= 0 {
+			return nil, fmt.Errorf("invalid MLFQ allotment %d", allotment)
+		}
+	}
+	if config.BoostInterval < 0 {
+		return nil, fmt.Errorf("invalid MLFQ boost interval %d", config.BoostInterval)
+	}
+	m := &MLFQScheduler{
+		config:  config,
+		queues:  make([]*list.List, config.Queues),
+		entries: make(map[int]*mlfqEntry),
+	}
+	for level := range m.queues {
+		m.queues[level] = list.New()
+	}
+	return m, nil
+}
+
+func (m *MLFQScheduler) quantum(level int) int64 {
+	return levelValue(m.config.Quantums, level, DefaultQuantum)
+}
+
+func (m *MLFQScheduler) allotment(level int) int64 {
+	return levelValue(m.config.Allotments, level, m.quantum(level))
+}
+
+func levelValue(values []int64, level int, fallback int64) int64 {
+	if len(values) == 0 {
+		return fallback
+	}
+	if level >= len(values) {
+		return values[len(values)-1]
+	}
+	return values[level]
+}
+
+func (m *MLFQScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	// queued jobs missing from the ready jobs have blocked
+	ready := make(map[int]bool, len(jobs))
+	for _, job := range jobs {
+		ready[job.ID] = true
+	}
+	for id, entry := range m.entries {
+		if entry.elem != nil && !ready[id] {
+			m.Dequeue(entry.job)
+		}
+	}
+	for _, job := range jobs {
+		m.Enqueue(job)
+	}
+
+	return m.Next()
+}
+
+// Enqueue puts a new job at the back of the topmost queue, and a job back
+// from I/O at the back, or with IOToFront the front, of its queue.
+func (m *MLFQScheduler) Enqueue(job Job) {
+	entry, ok := m.entries[job.ID]
+	if !ok {
+		entry = &mlfqEntry{}
+		m.entries[job.ID] = entry
+	}
+	entry.job = job
+	if entry.elem != nil {
+		return
+	}
+	if ok && m.config.IOToFront {
+		entry.elem = m.queues[entry.level].PushFront(entry)
+	} else {
+		entry.elem = m.queues[entry.level].PushBack(entry)
+	}
+}
+
+func (m *MLFQScheduler) Dequeue(job Job) {
+	if entry, ok := m.entries[job.ID]; ok && entry.elem != nil {
+		m.queues[entry.level].Remove(entry.elem)
+		entry.elem = nil
+	}
+}
+
+func (m *MLFQScheduler) Next() (Job, error) {
+	for _, queue := range m.queues {
+		if front := queue.Front(); front != nil {
+			return front.Value.(*mlfqEntry).job, nil
+		}
+	}
+	return Job{}, fmt.Errorf("no queued job is ready")
+}
+
+func (m *MLFQScheduler) TimeSlice(job Job) int64 {
+	entry, ok := m.entries[job.ID]
+	if !ok {
+		return m.quantum(0)
+	}
+	return m.quantum(entry.level)
+}
+
+func (m *MLFQScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	entry, ok := m.entries[job.ID]
+	if ok {
+		if finished {
+			m.Dequeue(job)
+			delete(m.entries, job.ID)
+		} else {
+			entry.used += elapsed
+			if entry.used >= m.allotment(entry.level) {
+				if entry.level < len(m.queues)-1 {
+					m.moveTo(entry, entry.level+1)
+				} else {
+					m.moveTo(entry, entry.level)
+				}
+				entry.used = 0
+			} else if elapsed >= m.quantum(entry.level) {
+				m.moveTo(entry, entry.level)
+			} else if m.config.ResetOnYield {
+				entry.used = 0
+			}
+		}
+	}
+
+	if m.config.BoostInterval > 0 && now-m.lastBoost >= m.config.BoostInterval {
+		m.boost()
+		m.lastBoost = now - (now-m.lastBoost)%m.config.BoostInterval
+	}
+}
+
+// moveTo puts entry at level, at the back of its queue if it is runnable.
+func (m *MLFQScheduler) moveTo(entry *mlfqEntry, level int) {
+	if entry.elem == nil {
+		entry.level = level
+		return
+	}
+	m.queues[entry.level].Remove(entry.elem)
+	entry.level = level
+	entry.elem = m.queues[level].PushBack(entry)
+}
+
+// boost moves every job to the topmost queue, keeping the relative order of
+// the runnable ones.
+func (m *MLFQScheduler) boost() {
+	top := list.New()
+	for _, queue := range m.queues {
+		for elem := queue.Front(); elem != nil; elem = elem.Next() {
+			entry := elem.Value.(*mlfqEntry)
+			entry.elem = top.PushBack(entry)
+		}
+	}
+	m.queues[0] = top
+	for level := 1; level < len(m.queues); level++ {
+		m.queues[level] = list.New()
+	}
+	for _, entry := range m.entries {
+		entry.level = 0
+		entry.used = 0
+	}
+}
@@ -0,0 +1,131 @@
+package cpu_schedule
+
+import (
+	"testing"
+)
+
+func newTestMLFQ(t *testing.T, config MLFQConfig) *MLFQScheduler {
+	t.Helper()
+	scheduler, err := NewMLFQScheduler(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scheduler
+}
+
+func mlfqJobs(names ...string) []Job {
+	jobs := make([]Job, len(names))
+	for idx, name := range names {
+		jobs[idx] = Job{ID: idx, Name: name, Length: 1000}
+	}
+	return jobs
+}
+
+// checkNext checks that the scheduler picks want next and tells it the job
+// ran for elapsed ms.
+func checkNext(t *testing.T, m *MLFQScheduler, want Job, now int64, elapsed int64) {
+	t.Helper()
+	got, err := m.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != want.Name {
+		t.Fatalf("at %dms picked %s, want %s", now, got.Name, want.Name)
+	}
+	m.Ran(got, now, elapsed, false)
+}
+
+func checkLevel(t *testing.T, m *MLFQScheduler, job Job, want int) {
+	t.Helper()
+	if got := m.entries[job.ID].level; got != want {
+		t.Errorf("%s is at level %d, want %d", job.Name, got, want)
+	}
+}
+
+func TestMLFQDemotesAfterAllotment(t *testing.T) {
+	m := newTestMLFQ(t, MLFQConfig{Queues: 3, Quantums: []int64{10}, Allotments: []int64{20}})
+	jobs := mlfqJobs("A", "B")
+	a, b := jobs[0], jobs[1]
+	m.Enqueue(a)
+	m.Enqueue(b)
+
+	checkNext(t, m, a, 10, 10)
+	checkNext(t, m, b, 20, 10)
+	checkNext(t, m, a, 30, 10)
+	checkLevel(t, m, a, 1)
+	checkNext(t, m, b, 40, 10)
+	checkLevel(t, m, b, 1)
+	checkNext(t, m, a, 50, 10)
+	checkNext(t, m, b, 60, 10)
+	checkNext(t, m, a, 70, 10)
+	checkNext(t, m, b, 80, 10)
+	// the bottom queue keeps both jobs
+	checkNext(t, m, a, 90, 10)
+	checkNext(t, m, b, 100, 10)
+	checkNext(t, m, a, 110, 10)
+	checkLevel(t, m, a, 2)
+	checkLevel(t, m, b, 2)
+}
+
+func TestMLFQBoost(t *testing.T) {
+	m := newTestMLFQ(t, MLFQConfig{Queues: 3, Quantums: []int64{10}, BoostInterval: 100})
+	jobs := mlfqJobs("A", "B")
+	a, b := jobs[0], jobs[1]
+	m.Enqueue(a)
+	checkNext(t, m, a, 10, 10)
+	checkNext(t, m, a, 20, 10)
+	checkLevel(t, m, a, 2)
+
+	m.Enqueue(b)
+	checkNext(t, m, b, 30, 10)
+	checkLevel(t, m, b, 1)
+	checkNext(t, m, b, 100, 10)
+	checkLevel(t, m, a, 0)
+	checkLevel(t, m, b, 0)
+	// A was ahead of B in the bottom queue before the boost
+	checkNext(t, m, a, 110, 10)
+}
+
+func TestMLFQResetOnYield(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		m := newTestMLFQ(t, MLFQConfig{Queues: 2, Quantums: []int64{10}, Allotments: []int64{20}, ResetOnYield: reset})
+		a := mlfqJobs("A")[0]
+		m.Enqueue(a)
+		// A gives up the CPU after 5ms every time, so it never uses a whole
+		// quantum
+		for now := int64(5); now <= 25; now += 5 {
+			checkNext(t, m, a, now, 5)
+		}
+		want := 1
+		if reset {
+			want = 0
+		}
+		checkLevel(t, m, a, want)
+	}
+}
+
+func TestMLFQRequeuesAfterIO(t *testing.T) {
+	for _, toFront := range []bool{false, true} {
+		m := newTestMLFQ(t, MLFQConfig{Queues: 1, Quantums: []int64{10}, IOToFront: toFront})
+		jobs := mlfqJobs("A", "B", "C")
+		a, b, c := jobs[0], jobs[1], jobs[2]
+		for _, job := range jobs {
+			m.Enqueue(job)
+		}
+
+		// A blocks after 5ms and its I/O is done by the end of B's slice
+		if got, _ := m.Next(); got.Name != a.Name {
+			t.Fatalf("picked %s, want A", got.Name)
+		}
+		m.Dequeue(a)
+		m.Ran(a, 5, 5, false)
+		checkNext(t, m, b, 15, 10)
+		m.Enqueue(a)
+
+		want := c
+		if toFront {
+			want = a
+		}
+		checkNext(t, m, want, 25, 10)
+	}
+}
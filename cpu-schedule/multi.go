@@ -0,0 +1,301 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"math"
+)
+
+// CacheModel describes how a job speeds up once the cache of the CPU it runs
+// on is warm with its data. A job's cache is only warm on the CPU it last ran
+// on; running anywhere else starts warming up from scratch.
+type CacheModel struct {
+	// WarmupTime is how long, in ms, a job has to run on a CPU before its
+	// cache there is warm.
+	WarmupTime int64
+	// WarmSpeedup is how many ms of work a job gets done per ms on a warm
+	// CPU. Values of 1 or less disable the model.
+	WarmSpeedup float64
+}
+
+// MultiConfig configures a MultiSimulator.
+type MultiConfig struct {
+	CPUs int
+	// Quantum is the length, in ms, of the time slice every CPU runs before
+	// they all schedule again. It defaults to DefaultQuantum.
+	Quantum int64
+	// PerCPUQueues gives every CPU its own scheduler and queue. Jobs are put
+	// on the queue with the fewest jobs when they arrive. Otherwise all CPUs
+	// share a single scheduler.
+	PerCPUQueues bool
+	// WorkStealing lets a CPU whose queue has nothing runnable migrate a
+	// job from the busiest queue. It only applies with PerCPUQueues.
+	WorkStealing bool
+	Cache        CacheModel
+	// Starvation flags a job as starved when it once waited longer than
+	// this many ms for a CPU. Zero disables it.
+	Starvation int64
+}
+
+// MultiSimulator is the multiprocessor counterpart of Simulator, modelled on
+// OSTEP chapter 10's multi.py. All CPUs run in lockstep, one quantum at a
+// time, so a job that arrives during a quantum waits for the next one before
+// it can get a CPU, and a scheduler's TimeSlicer is ignored. I/O bursts are
+// not modelled: a job's bursts are run back to back.
+type MultiSimulator struct {
+	config MultiConfig
+}
+
+func NewMultiSimulator(config MultiConfig) *MultiSimulator {
+	if config.CPUs <= 0 {
+		config.CPUs = 1
+	}
+	if config.Quantum <= 0 {
+		config.Quantum = DefaultQuantum
+	}
+	return &MultiSimulator{config: config}
+}
+
+type multiJob struct {
+	job        Job
+	remaining  float64
+	queue      int
+	lastCPU    int
+	warmTime   int64
+	ran        int64
+	firstRun   int64
+	completion int64
+	// readySince is when the job last started waiting for a CPU
+	readySince int64
+	maxWait    int64
+}
+
+// Run simulates jobs on all CPUs. newScheduler is called once for the shared
+// queue, or once per CPU with PerCPUQueues. The jobs slice is not modified.
+// Like Simulator.Run, it fails if the jobs do not pass ValidateJobs or a
+// scheduler fails.
+func (m *MultiSimulator) Run(newScheduler func() Scheduler, jobs []Job) (SimulationResult, error) {
+	cpus := m.config.CPUs
+	queueCount := 1
+	if m.config.PerCPUQueues {
+		queueCount = cpus
+	}
+	schedulers := make([]Scheduler, queueCount)
+	for idx := range schedulers {
+		schedulers[idx] = newScheduler()
+	}
+
+	pending, ids, err := prepareJobs(jobs)
+	if err != nil {
+		return SimulationResult{}, err
+	}
+	states := make([]*multiJob, len(pending))
+	queues := make([][]*multiJob, queueCount)
+	busy := make([]int64, cpus)
+	runs := make([]Run, 0)
+	migrations := 0
+	remaining := len(pending)
+
+	now := int64(0)
+	for remaining > 0 {
+		// put the newly arrived jobs on the shortest queue
+		for len(pending) > 0 && pending[0].Arrival <= now {
+			job := pending[0]
+			pending = pending[1:]
+			shortest := 0
+			for idx := range queues {
+				if len(queues[idx]) < len(queues[shortest]) {
+					shortest = idx
+				}
+			}
+			state := &multiJob{job: job, remaining: float64(job.Length), queue: shortest, lastCPU: -1, firstRun: -1, readySince: job.Arrival}
+			states[job.ID] = state
+			queues[shortest] = append(queues[shortest], state)
+		}
+
+		if m.config.PerCPUQueues && m.config.WorkStealing {
+			migrations += m.steal(queues)
+		}
+
+		picked := make(map[int]bool)
+		ranAny := false
+		for cpu := 0; cpu < cpus; cpu++ {
+			queue := 0
+			if m.config.PerCPUQueues {
+				queue = cpu
+			}
+
+			ready := make([]Job, 0, len(queues[queue]))
+			for _, state := range queues[queue] {
+				if !picked[state.job.ID] {
+					ready = append(ready, state.job)
+				}
+			}
+			if len(ready) == 0 {
+				continue
+			}
+
+			job, err := schedulers[queue].Schedule(ready)
+			if err != nil {
+				return SimulationResult{}, fmt.Errorf("scheduling CPU %d at %dms: %w", cpu, now, err)
+			}
+			if job.ID < 0 || job.ID >= len(states) || !queued(queues[queue], states[job.ID]) || picked[job.ID] {
+				return SimulationResult{}, fmt.Errorf("scheduling CPU %d at %dms: picked %s which is not ready", cpu, now, job.Name)
+			}
+			picked[job.ID] = true
+			ranAny = true
+
+			state := states[job.ID]
+			if state.firstRun == -1 {
+				state.firstRun = now
+			}
+			state.maxWait = max(state.maxWait, now-state.readySince)
+			elapsed := m.run(state, cpu)
+			state.readySince = now + elapsed
+			busy[cpu] += elapsed
+			runs = append(runs, Run{Job: job.Name, CPU: cpu, Start: now, End: now + elapsed})
+
+			finished := state.remaining <= 0
+			if finished {
+				state.completion = now + elapsed
+				queues[queue] = removeMultiJob(queues[queue], state)
+				remaining--
+			}
+			if fs, ok := schedulers[queue].(FeedbackScheduler); ok {
+				fs.Ran(job, now+elapsed, elapsed, finished)
+			}
+		}
+
+		if !ranAny && len(pending) > 0 {
+			now = pending[0].Arrival
+			continue
+		}
+		now += m.config.Quantum
+	}
+
+	return m.result(ids, states, busy, runs, migrations), nil
+}
+
+// run lets the job use the CPU for up to a quantum and returns how long it
+// actually ran.
+func (m *MultiSimulator) run(state *multiJob, cpu int) int64 {
+	if state.lastCPU != cpu {
+		state.lastCPU = cpu
+		state.warmTime = 0
+	}
+
+	elapsed := int64(0)
+	speedup := m.config.Cache.WarmSpeedup
+	if speedup > 1 && state.warmTime < m.config.Cache.WarmupTime {
+		cold := min(m.config.Quantum, m.config.Cache.WarmupTime-state.warmTime, int64(math.Ceil(state.remaining)))
+		state.remaining -= float64(cold)
+		state.warmTime += cold
+		elapsed += cold
+	}
+	if state.remaining > 0 && elapsed < m.config.Quantum {
+		rate := float64(1)
+		if speedup > 1 {
+			rate = speedup
+		}
+		warm := min(m.config.Quantum-elapsed, int64(math.Ceil(state.remaining/rate)))
+		state.remaining -= float64(warm) * rate
+		state.warmTime += warm
+		elapsed += warm
+	}
+	state.ran += elapsed
+	return elapsed
+}
+
+// steal moves a job to every queue with nothing to run from the queue with
+// the most jobs, as long as that queue has more than one. It returns the
+// number of migrated jobs.
+func (m *MultiSimulator) steal(queues [][]*multiJob) int {
+	migrations := 0
+	for idx := range queues {
+		if len(queues[idx]) > 0 {
+			continue
+		}
+
+		busiest := 0
+		for victim := range queues {
+			if len(queues[victim]) > len(queues[busiest]) {
+				busiest = victim
+			}
+		}
+		if len(queues[busiest]) < 2 {
+			return migrations
+		}
+
+		victim := queues[busiest][len(queues[busiest])-1]
+		queues[busiest] = queues[busiest][:len(queues[busiest])-1]
+		victim.queue = idx
+		queues[idx] = append(queues[idx], victim)
+		migrations++
+	}
+	return migrations
+}
+
+func queued(queue []*multiJob, state *multiJob) bool {
+	for _, other := range queue {
+		if other == state {
+			return true
+		}
+	}
+	return false
+}
+
+func removeMultiJob(queue []*multiJob, victim *multiJob) []*multiJob {
+	for idx, state := range queue {
+		if state == victim {
+			return append(queue[:idx], queue[idx+1:]...)
+		}
+	}
+	return queue
+}
+
+func (m *MultiSimulator) result(ids []int, states []*multiJob, busy []int64, runs []Run, migrations int) SimulationResult {
+	total := int64(0)
+	for _, state := range states {
+		total = max(total, state.completion)
+	}
+
+	result := SimulationResult{
+		Jobs:       make([]JobResult, 0, len(ids)),
+		Runs:       runs,
+		TotalTime:  total,
+		CPUs:       make([]CPUResult, len(busy)),
+		Migrations: migrations,
+	}
+	for cpu, b := range busy {
+		result.BusyTime += b
+		result.CPUs[cpu] = CPUResult{BusyTime: b, IdleTime: total - b}
+		if total > 0 {
+			result.CPUs[cpu].Utilization = float64(b) / float64(total)
+		}
+	}
+	result.IdleTime = total*int64(len(busy)) - result.BusyTime
+	if total > 0 {
+		result.Utilization = float64(result.BusyTime) / float64(total*int64(len(busy)))
+	}
+
+	for _, id := range ids {
+		state := states[id]
+		jr := JobResult{
+			Name:       state.job.Name,
+			Arrival:    state.job.Arrival,
+			Length:     state.job.Length,
+			FirstRun:   state.firstRun,
+			Completion: state.completion,
+		}
+		jr.Response = jr.FirstRun - jr.Arrival
+		jr.Turnaround = jr.Completion - jr.Arrival
+		// a job on a warm cache runs for less than its Length
+		jr.Wait = jr.Turnaround - state.ran
+		jr.MaxWait = state.maxWait
+		jr.Starved = m.config.Starvation > 0 && jr.MaxWait > m.config.Starvation
+		result.MaxWait = max(result.MaxWait, jr.MaxWait)
+		result.Jobs = append(result.Jobs, jr)
+	}
+	result.summarize()
+
+	return result
+}
@@ -0,0 +1,87 @@
+package cpu_schedule
+
+import (
+	"testing"
+)
+
+func newFIFO() Scheduler { return NewFIFOScheduler() }
+
+// stealJobs puts A and C on CPU 0's queue and B on CPU 1's, so CPU 1 runs
+// out of work at 10ms.
+var stealJobs = []Job{
+	{Name: "A", Length: 100},
+	{Name: "B", Length: 10},
+	{Name: "C", Length: 100},
+}
+
+func TestMultiCacheAffinity(t *testing.T) {
+	jobs := []Job{{Name: "A", Length: 100}, {Name: "B", Length: 100}}
+	for _, tt := range []struct {
+		cache CacheModel
+		want  int64
+	}{
+		{CacheModel{}, 100},
+		// 10ms cold, then the other 90ms of work at twice the speed
+		{CacheModel{WarmupTime: 10, WarmSpeedup: 2}, 55},
+	} {
+		config := MultiConfig{CPUs: 2, Quantum: 10, PerCPUQueues: true, Cache: tt.cache}
+		result, err := NewMultiSimulator(config).Run(newFIFO, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, job := range result.Jobs {
+			if job.Completion != tt.want {
+				t.Errorf("%+v: %s completed at %d, want %d", tt.cache, job.Name, job.Completion, tt.want)
+			}
+		}
+	}
+}
+
+func TestMultiWorkStealing(t *testing.T) {
+	for _, tt := range []struct {
+		stealing   bool
+		migrations int
+		total      int64
+	}{
+		{false, 0, 200},
+		{true, 1, 110},
+	} {
+		config := MultiConfig{CPUs: 2, Quantum: 10, PerCPUQueues: true, WorkStealing: tt.stealing}
+		result, err := NewMultiSimulator(config).Run(newFIFO, stealJobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Migrations != tt.migrations || result.TotalTime != tt.total {
+			t.Errorf("stealing %v: %d migrations and total %d, want %d and %d",
+				tt.stealing, result.Migrations, result.TotalTime, tt.migrations, tt.total)
+		}
+	}
+}
+
+func TestMultiPerCPUResults(t *testing.T) {
+	config := MultiConfig{CPUs: 2, Quantum: 10, PerCPUQueues: true, Starvation: 50}
+	result, err := NewMultiSimulator(config).Run(newFIFO, stealJobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CPUResult{
+		{BusyTime: 200, IdleTime: 0, Utilization: 1},
+		{BusyTime: 10, IdleTime: 190, Utilization: 0.05},
+	}
+	for cpu, got := range result.CPUs {
+		if got != want[cpu] {
+			t.Errorf("CPU %d: got %+v, want %+v", cpu, got, want[cpu])
+		}
+	}
+
+	// C waits behind A for the whole of A
+	if result.MaxWait != 100 {
+		t.Errorf("max wait %d, want 100", result.MaxWait)
+	}
+	for _, job := range result.Jobs {
+		if job.Starved != (job.Name == "C") {
+			t.Errorf("%s starved: %v", job.Name, job.Starved)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package cpu_schedule
+
+import (
+	"fmt"
+)
+
+// PriorityConfig configures a PriorityScheduler.
+type PriorityConfig struct {
+	// Preemptive lets a more important job take the CPU from the running
+	// one at the end of its time slice, or as soon as it arrives with
+	// WithPreemptOnArrival. Otherwise the running job keeps the CPU until it
+	// finishes or blocks.
+	Preemptive bool
+	// AgingInterval and AgingStep raise the priority of waiting jobs: for
+	// every AgingInterval ms a job goes without the CPU its Priority is
+	// lowered by AgingStep. A zero AgingInterval disables aging.
+	AgingInterval int64
+	AgingStep     int
+}
+
+// PriorityScheduler runs the job with the lowest Priority value.
+//
+// It does not implement QueueScheduler: with aging, every waiting job's
+// priority changes with time, so each pick scans all ready jobs and costs
+// O(n).
+type PriorityScheduler struct {
+	TieBreak TieBreak
+	config   PriorityConfig
+	// now is the end of the last time slice
+	now          int64
+	waitingSince map[int]int64
+	// executed is how long each job has run, to tell when it blocks
+	executed map[int]int64
+	// running is the ID of the job that ran last, or -1
+	running int
+}
+
+func NewPriorityScheduler(config PriorityConfig) *PriorityScheduler {
+	return &PriorityScheduler{
+		config:       config,
+		waitingSince: make(map[int]int64),
+		executed:     make(map[int]int64),
+		running:      -1,
+	}
+}
+
+// effectivePriority is the job's Priority after aging.
+func (p *PriorityScheduler) effectivePriority(job Job, now int64) int64 {
+	priority := int64(job.Priority)
+	if p.config.AgingInterval > 0 {
+		waited := now - p.waitingSince[job.ID]
+		priority -= waited / p.config.AgingInterval * int64(p.config.AgingStep)
+	}
+	return priority
+}
+
+func (p *PriorityScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	// the scheduler only learns the time from Ran, so a job that arrived
+	// after the CPU went idle moves the clock forward
+	now := p.now
+	for _, job := range jobs {
+		if _, ok := p.waitingSince[job.ID]; !ok {
+			p.waitingSince[job.ID] = max(p.now, job.Arrival)
+		}
+		now = max(now, job.Arrival)
+	}
+
+	if !p.config.Preemptive {
+		for _, job := range jobs {
+			if job.ID == p.running {
+				return job, nil
+			}
+		}
+	}
+
+	best := p.TieBreak.pickBest(jobs, func(job Job) int64 {
+		return p.effectivePriority(job, now)
+	})
+	p.running = best.ID
+	return best, nil
+}
+
+// Ran restarts the job's wait. A job that blocks for I/O only starts waiting
+// once it is ready again, so its time blocked does not age it.
+func (p *PriorityScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	p.now = now
+	if finished {
+		delete(p.waitingSince, job.ID)
+		delete(p.executed, job.ID)
+		p.running = -1
+		return
+	}
+	p.executed[job.ID] += elapsed
+	io := blockedFor(job, p.executed[job.ID])
+	if io > 0 {
+		p.running = -1
+	}
+	p.waitingSince[job.ID] = now + io
+}
+
+// blockedFor returns how long job blocks for I/O after it has run for
+// executed ms in total, or 0 if that is not the end of one of its bursts.
+func blockedFor(job Job, executed int64) int64 {
+	bursts := job.bursts()
+	end := int64(0)
+	for _, burst := range bursts[:len(bursts)-1] {
+		end += burst.CPU
+		if end >= executed {
+			if end == executed {
+				return burst.IO
+			}
+			break
+		}
+	}
+	return 0
+}
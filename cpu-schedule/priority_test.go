@@ -0,0 +1,79 @@
+package cpu_schedule
+
+import (
+	"testing"
+)
+
+func runPriority(t *testing.T, config PriorityConfig, starvation int64, jobs []Job) SimulationResult {
+	t.Helper()
+	result, err := NewSimulator(WithStarvationThreshold(starvation)).Run(NewPriorityScheduler(config), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func checkCompletions(t *testing.T, name string, result SimulationResult, want map[string]int64) {
+	t.Helper()
+	for _, job := range result.Jobs {
+		if completion, ok := want[job.Name]; ok && job.Completion != completion {
+			t.Errorf("%s: %s completed at %d, want %d", name, job.Name, job.Completion, completion)
+		}
+	}
+}
+
+func TestPriorityPreemption(t *testing.T) {
+	jobs := []Job{
+		{Name: "L", Length: 3000, Priority: 5},
+		{Name: "H", Arrival: 1000, Length: 1000, Priority: 1},
+	}
+	preemptive := runPriority(t, PriorityConfig{Preemptive: true}, 0, jobs)
+	checkCompletions(t, "preemptive", preemptive, map[string]int64{"H": 2000, "L": 4000})
+	nonPreemptive := runPriority(t, PriorityConfig{}, 0, jobs)
+	checkCompletions(t, "non-preemptive", nonPreemptive, map[string]int64{"L": 3000, "H": 4000})
+
+	scheduler, err := MakeScheduler("PRIORITY", SchedulerOptions{NonPreemptive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheduler.(*PriorityScheduler).config.Preemptive {
+		t.Error("PRIORITY ignored NonPreemptive")
+	}
+}
+
+func TestPriorityAging(t *testing.T) {
+	jobs := []Job{
+		{Name: "M", Length: 10000, Priority: 3},
+		{Name: "L", Length: 1000, Priority: 5},
+	}
+	// without aging L waits for all of M and is flagged as starved
+	result := runPriority(t, PriorityConfig{Preemptive: true}, 5000, jobs)
+	checkCompletions(t, "no aging", result, map[string]int64{"M": 10000, "L": 11000})
+	for _, job := range result.Jobs {
+		if job.Starved != (job.Name == "L") {
+			t.Errorf("no aging: %s starved: %v", job.Name, job.Starved)
+		}
+	}
+
+	// L ties with M after waiting 2s and runs once it passes M at 3s
+	config := PriorityConfig{Preemptive: true, AgingInterval: 1000, AgingStep: 1}
+	result = runPriority(t, config, 5000, jobs)
+	checkCompletions(t, "aging", result, map[string]int64{"L": 4000, "M": 11000})
+	for _, job := range result.Jobs {
+		if job.Starved {
+			t.Errorf("aging: %s starved", job.Name)
+		}
+	}
+}
+
+func TestPriorityAgingSkipsIO(t *testing.T) {
+	// H ages past M, runs and blocks for 20s. Back from I/O it has to wait
+	// another 2s before it catches up with M again.
+	jobs := []Job{
+		{Name: "H", Priority: 5, Bursts: []Burst{{CPU: 1000, IO: 20000}, {CPU: 1000}}},
+		{Name: "M", Length: 30000, Priority: 3},
+	}
+	config := PriorityConfig{Preemptive: true, AgingInterval: 1000, AgingStep: 1}
+	result := runPriority(t, config, 0, jobs)
+	checkCompletions(t, "I/O", result, map[string]int64{"H": 26000})
+}
@@ -0,0 +1,155 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// LotteryScheduler picks the next job by drawing a winning ticket, so each
+// job runs with a probability proportional to its Tickets. Runs with the same
+// seed are reproducible.
+//
+// It does not implement QueueScheduler. Each draw sums the tickets of all
+// ready jobs and walks them to find the winner, so it costs O(n).
+type LotteryScheduler struct {
+	rand *rand.Rand
+}
+
+func NewLotteryScheduler(seed int64) *LotteryScheduler {
+	return &LotteryScheduler{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (l *LotteryScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	total := uint64(0)
+	for _, job := range jobs {
+		total += job.tickets()
+	}
+
+	winner := uint64(l.rand.Int63n(int64(total)))
+	counter := uint64(0)
+	for _, job := range jobs {
+		counter += job.tickets()
+		if counter > winner {
+			return job, nil
+		}
+	}
+
+	return jobs[len(jobs)-1], nil
+}
+
+// strideLarge is divided by a job's tickets to get its stride. It is large
+// enough that the integer division barely skews the shares of jobs with up to
+// MaxTickets tickets.
+const strideLarge = uint64(1) << 30
+
+// StrideScheduler is the deterministic counterpart of LotteryScheduler: each
+// job has a pass value that grows by its stride for every ms it runs, and the
+// job with the lowest pass runs next.
+type StrideScheduler struct {
+	TieBreak TieBreak
+	pass     map[int]uint64
+	// start is the lowest pass of the runnable jobs the last time there was
+	// one, and the pass new jobs start at
+	start uint64
+	queue *jobQueue
+	// fresh holds queued jobs that have no pass yet. They get one when Next
+	// is called, so that jobs waking up at the same time still count.
+	fresh []Job
+}
+
+func NewStrideScheduler() *StrideScheduler {
+	return &StrideScheduler{pass: make(map[int]uint64)}
+}
+
+func (s *StrideScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	// start new jobs at the lowest pass of the runnable jobs so they cannot
+	// monopolize the CPU while catching up
+	start := s.minPass(jobs)
+	for _, job := range jobs {
+		if _, ok := s.pass[job.ID]; !ok {
+			s.pass[job.ID] = start
+		}
+	}
+
+	return s.TieBreak.pickBest(jobs, func(job Job) int64 {
+		return int64(s.pass[job.ID])
+	}), nil
+}
+
+// minPass returns the lowest pass of the jobs that already have one. Jobs
+// that are blocked or done do not count. If no job has a pass yet, it returns
+// the pass new jobs were last started at.
+func (s *StrideScheduler) minPass(jobs []Job) uint64 {
+	found := false
+	lowest := uint64(0)
+	for _, job := range jobs {
+		if pass, ok := s.pass[job.ID]; ok && (!found || pass < lowest) {
+			lowest = pass
+			found = true
+		}
+	}
+	if found {
+		s.start = lowest
+	}
+	return s.start
+}
+
+func (s *StrideScheduler) passOf(job Job) int64 {
+	return int64(s.pass[job.ID])
+}
+
+func (s *StrideScheduler) ready() *jobQueue {
+	if s.queue == nil {
+		s.queue = newJobQueue(s.passOf, s.TieBreak)
+	}
+	return s.queue
+}
+
+func (s *StrideScheduler) Enqueue(job Job) {
+	if _, ok := s.pass[job.ID]; !ok {
+		s.fresh = append(s.fresh, job)
+		return
+	}
+	s.ready().push(job)
+}
+
+func (s *StrideScheduler) Dequeue(job Job) {
+	for i, fresh := range s.fresh {
+		if fresh.ID == job.ID {
+			s.fresh = append(s.fresh[:i], s.fresh[i+1:]...)
+			return
+		}
+	}
+	s.ready().remove(job)
+}
+
+func (s *StrideScheduler) Next() (Job, error) {
+	if head, err := s.ready().peek(); err == nil {
+		s.start = s.pass[head.ID]
+	}
+	for _, job := range s.fresh {
+		s.pass[job.ID] = s.start
+		s.ready().push(job)
+	}
+	s.fresh = s.fresh[:0]
+	return s.ready().peek()
+}
+
+func (s *StrideScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	if finished {
+		delete(s.pass, job.ID)
+		return
+	}
+	s.pass[job.ID] += strideLarge / job.tickets() * uint64(elapsed)
+	if s.queue != nil {
+		s.queue.fix(job)
+	}
+}
@@ -0,0 +1,49 @@
+package cpu_schedule
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStrideSharesWithManyTickets(t *testing.T) {
+	jobs := []Job{
+		{Name: "A", Length: 100000, Tickets: MaxTickets},
+		{Name: "B", Length: 100000, Tickets: MaxTickets / 2},
+		{Name: "C", Length: 100000, Tickets: MaxTickets / 3},
+	}
+	result, err := NewSimulator(WithQuantum(10)).Run(NewStrideScheduler(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := result.CPUShares(60000)[0].Shares
+	for name, want := range map[string]float64{"A": 6.0 / 11, "B": 3.0 / 11, "C": 2.0 / 11} {
+		if math.Abs(shares[name]-want) > 0.01 {
+			t.Errorf("%s got %.3f of the CPU, want %.3f", name, shares[name], want)
+		}
+	}
+
+	jobs[0].Tickets = MaxTickets + 1
+	if _, err := NewSimulator().Run(NewStrideScheduler(), jobs); err == nil {
+		t.Error("Run accepted more than MaxTickets tickets")
+	}
+}
+
+func TestStrideStartsNewJobsAtRunnablePass(t *testing.T) {
+	// A blocks for a long time early on, so its pass stays low; C must start
+	// level with B rather than with A and share the CPU evenly with B
+	jobs := []Job{
+		{Name: "A", Bursts: []Burst{{CPU: 1000, IO: 50000}, {CPU: 1000}}},
+		{Name: "B", Length: 40000},
+		{Name: "C", Arrival: 20000, Length: 20000},
+	}
+	result, err := NewSimulator().Run(NewStrideScheduler(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := result.CPUShares(20000)[1].Shares
+	if math.Abs(shares["B"]-0.5) > 0.05 || math.Abs(shares["C"]-0.5) > 0.05 {
+		t.Errorf("B and C got %.2f and %.2f of the CPU after C arrived, want 0.5 each", shares["B"], shares["C"])
+	}
+}
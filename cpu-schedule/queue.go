@@ -0,0 +1,95 @@
+package cpu_schedule
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// QueueScheduler is implemented by schedulers that keep their own ready
+// queue. Instead of passing every ready job to Schedule, the Simulator calls
+// Enqueue when a job arrives or finishes its I/O, Dequeue when it blocks or
+// finishes, and Next to pick one of the queued jobs. This keeps every pick
+// O(log n) for large workloads.
+type QueueScheduler interface {
+	Scheduler
+	Enqueue(job Job)
+	Dequeue(job Job)
+	Next() (Job, error)
+}
+
+// jobQueue is a min-heap of jobs by a scheduler's key, then its TieBreak,
+// then ID, so its head is the job pickBest would choose from the same jobs in
+// ID order. Whoever changes a queued job's key must call fix.
+type jobQueue struct {
+	jobs []Job
+	// index maps a job's ID to its position in jobs
+	index    map[int]int
+	key      func(job Job) int64
+	tieBreak TieBreak
+}
+
+func newJobQueue(key func(job Job) int64, tieBreak TieBreak) *jobQueue {
+	return &jobQueue{
+		index:    make(map[int]int),
+		key:      key,
+		tieBreak: tieBreak,
+	}
+}
+
+func (q *jobQueue) Len() int { return len(q.jobs) }
+
+func (q *jobQueue) Less(i, j int) bool {
+	a, b := q.jobs[i], q.jobs[j]
+	if ka, kb := q.key(a), q.key(b); ka != kb {
+		return ka < kb
+	}
+	if q.tieBreak.before(a, b) {
+		return true
+	}
+	if q.tieBreak.before(b, a) {
+		return false
+	}
+	return a.ID < b.ID
+}
+
+func (q *jobQueue) Swap(i, j int) {
+	q.jobs[i], q.jobs[j] = q.jobs[j], q.jobs[i]
+	q.index[q.jobs[i].ID] = i
+	q.index[q.jobs[j].ID] = j
+}
+
+func (q *jobQueue) Push(x any) {
+	job := x.(Job)
+	q.index[job.ID] = len(q.jobs)
+	q.jobs = append(q.jobs, job)
+}
+
+func (q *jobQueue) Pop() any {
+	last := q.jobs[len(q.jobs)-1]
+	q.jobs = q.jobs[:len(q.jobs)-1]
+	delete(q.index, last.ID)
+	return last
+}
+
+func (q *jobQueue) push(job Job) {
+	heap.Push(q, job)
+}
+
+func (q *jobQueue) remove(job Job) {
+	if idx, ok := q.index[job.ID]; ok {
+		heap.Remove(q, idx)
+	}
+}
+
+func (q *jobQueue) fix(job Job) {
+	if idx, ok := q.index[job.ID]; ok {
+		heap.Fix(q, idx)
+	}
+}
+
+func (q *jobQueue) peek() (Job, error) {
+	if len(q.jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs queued")
+	}
+	return q.jobs[0], nil
+}
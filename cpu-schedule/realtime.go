@@ -0,0 +1,218 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// absoluteDeadline returns the time by which the job should be done, if it
+// has a deadline.
+func (j Job) absoluteDeadline() (int64, bool) {
+	switch {
+	case j.Deadline > 0:
+		return j.Arrival + j.Deadline, true
+	case j.Period > 0:
+		return j.Arrival + j.Period, true
+	default:
+		return 0, false
+	}
+}
+
+// relativeDeadline is the deadline of a periodic task relative to each
+// release.
+func (j Job) relativeDeadline() int64 {
+	if j.Deadline > 0 {
+		return j.Deadline
+	}
+	return j.Period
+}
+
+// ExpandPeriodic turns every periodic job into the jobs it releases before
+// horizon: one at Arrival, one at Arrival+Period and so on. Released jobs are
+// named "<name>#<n>" and have Task set to the name of the periodic job. Jobs
+// without a Period are returned unchanged. Pass the result to Simulator.Run.
+func ExpandPeriodic(jobs []Job, horizon int64) []Job {
+	expanded := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Period <= 0 {
+			expanded = append(expanded, job)
+			continue
+		}
+		for n, release := 0, job.Arrival; release < horizon; n, release = n+1, release+job.Period {
+			instance := job
+			instance.Name = fmt.Sprintf("%s#%d", job.Name, n)
+			instance.Arrival = release
+			instance.Task = job.Name
+			expanded = append(expanded, instance)
+		}
+	}
+	return expanded
+}
+
+// EDFScheduler is Earliest-Deadline-First: it picks the job whose absolute
+// deadline is closest. Jobs without a deadline only run when no job with one
+// is ready. Run it with WithPreemptOnArrival so new jobs preempt right away.
+type EDFScheduler struct {
+	TieBreak TieBreak
+	queue    *jobQueue
+}
+
+func NewEDFScheduler() *EDFScheduler {
+	return &EDFScheduler{}
+}
+
+func edfKey(job Job) int64 {
+	if deadline, ok := job.absoluteDeadline(); ok {
+		return deadline
+	}
+	return math.MaxInt64
+}
+
+func (e *EDFScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	return e.TieBreak.pickBest(jobs, edfKey), nil
+}
+
+func (e *EDFScheduler) ready() *jobQueue {
+	if e.queue == nil {
+		e.queue = newJobQueue(edfKey, e.TieBreak)
+	}
+	return e.queue
+}
+
+func (e *EDFScheduler) Enqueue(job Job) { e.ready().push(job) }
+func (e *EDFScheduler) Dequeue(job Job) { e.ready().remove(job) }
+func (e *EDFScheduler) Next() (Job, error) {
+	return e.ready().peek()
+}
+
+// RateMonotonicScheduler gives every periodic task a fixed priority: the
+// shorter its Period, the higher. Jobs without a period only run when no
+// periodic job is ready. Run it with WithPreemptOnArrival so new jobs preempt
+// right away.
+type RateMonotonicScheduler struct {
+	TieBreak TieBreak
+	queue    *jobQueue
+}
+
+func NewRateMonotonicScheduler() *RateMonotonicScheduler {
+	return &RateMonotonicScheduler{}
+}
+
+func rmKey(job Job) int64 {
+	if job.Period > 0 {
+		return job.Period
+	}
+	return math.MaxInt64
+}
+
+func (r *RateMonotonicScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
+	}
+
+	return r.TieBreak.pickBest(jobs, rmKey), nil
+}
+
+func (r *RateMonotonicScheduler) ready() *jobQueue {
+	if r.queue == nil {
+		r.queue = newJobQueue(rmKey, r.TieBreak)
+	}
+	return r.queue
+}
+
+func (r *RateMonotonicScheduler) Enqueue(job Job) { r.ready().push(job) }
+func (r *RateMonotonicScheduler) Dequeue(job Job) { r.ready().remove(job) }
+func (r *RateMonotonicScheduler) Next() (Job, error) {
+	return r.ready().peek()
+}
+
+// TaskAnalysis is the rate-monotonic response-time analysis of one task.
+type TaskAnalysis struct {
+	Name        string
+	Utilization float64
+	// ResponseTime is the worst-case response time under rate-monotonic
+	// scheduling. It is -1 when it exceeds the deadline.
+	ResponseTime int64
+	Schedulable  bool
+}
+
+// SchedulabilityReport is returned by AnalyzeSchedulability.
+type SchedulabilityReport struct {
+	// Utilization is the sum of Length/Period over all tasks.
+	Utilization float64
+	// EDFSchedulable is exact for tasks whose deadline is their period and
+	// sufficient otherwise: it checks that the sum of Length/min(Deadline,
+	// Period) is at most 1.
+	EDFSchedulable bool
+	// RMBound is the Liu & Layland bound n(2^(1/n)-1). Utilization at or
+	// below it is sufficient for rate-monotonic scheduling.
+	RMBound float64
+	// RMSchedulable is the exact result of the response-time analysis.
+	RMSchedulable bool
+	// Tasks are in rate-monotonic priority order.
+	Tasks []TaskAnalysis
+}
+
+// AnalyzeSchedulability checks whether the periodic jobs, those with a
+// Period, can always meet their deadlines on a single CPU. Other jobs are
+// ignored.
+func AnalyzeSchedulability(jobs []Job) SchedulabilityReport {
+	tasks := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Period > 0 {
+			tasks = append(tasks, job)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Period < tasks[j].Period
+	})
+
+	report := SchedulabilityReport{RMSchedulable: true}
+	density := float64(0)
+	for _, task := range tasks {
+		report.Utilization += float64(task.Length) / float64(task.Period)
+		density += float64(task.Length) / float64(min(task.relativeDeadline(), task.Period))
+	}
+	report.EDFSchedulable = density <= 1
+	if n := float64(len(tasks)); n > 0 {
+		report.RMBound = n * (math.Pow(2, 1/n) - 1)
+	}
+
+	for idx, task := range tasks {
+		response := responseTime(task, tasks[:idx])
+		analysis := TaskAnalysis{
+			Name:         task.Name,
+			Utilization:  float64(task.Length) / float64(task.Period),
+			ResponseTime: response,
+			Schedulable:  response != -1,
+		}
+		report.RMSchedulable = report.RMSchedulable && analysis.Schedulable
+		report.Tasks = append(report.Tasks, analysis)
+	}
+
+	return report
+}
+
+// responseTime iterates R = C + sum(ceil(R/T_j) * C_j) over the higher
+// priority tasks until it converges, or returns -1 once R passes the
+// deadline.
+func responseTime(task Job, higher []Job) int64 {
+	deadline := task.relativeDeadline()
+	response := int64(task.Length)
+	for response <= deadline {
+		next := int64(task.Length)
+		for _, h := range higher {
+			next += (response + h.Period - 1) / h.Period * int64(h.Length)
+		}
+		if next == response {
+			return response
+		}
+		response = next
+	}
+	return -1
+}
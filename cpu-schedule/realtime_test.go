@@ -0,0 +1,58 @@
+package cpu_schedule
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeSchedulabilityResponseTimes(t *testing.T) {
+	jobs := []Job{
+		{Name: "T3", Length: 3000, Period: 12000},
+		{Name: "T1", Length: 1000, Period: 4000},
+		{Name: "T2", Length: 2000, Period: 6000},
+	}
+	report := AnalyzeSchedulability(jobs)
+
+	want := []TaskAnalysis{
+		{Name: "T1", Utilization: 0.25, ResponseTime: 1000, Schedulable: true},
+		{Name: "T2", Utilization: 1.0 / 3, ResponseTime: 3000, Schedulable: true},
+		{Name: "T3", Utilization: 0.25, ResponseTime: 10000, Schedulable: true},
+	}
+	if !reflect.DeepEqual(report.Tasks, want) {
+		t.Errorf("got %+v, want %+v", report.Tasks, want)
+	}
+	if !report.RMSchedulable || !report.EDFSchedulable {
+		t.Errorf("RM schedulable %v, EDF schedulable %v, want both", report.RMSchedulable, report.EDFSchedulable)
+	}
+}
+
+func TestEDFMeetsDeadlinesRMMisses(t *testing.T) {
+	// utilization 2/5 + 4/7 is above the RM bound but below 1: RM makes the
+	// first release of T2 finish at 8, after its deadline at 7
+	tasks := []Job{
+		{Name: "T1", Length: 2, Period: 5},
+		{Name: "T2", Length: 4, Period: 7},
+	}
+	jobs := ExpandPeriodic(tasks, 35)
+
+	report := AnalyzeSchedulability(tasks)
+	if report.RMSchedulable || !report.EDFSchedulable {
+		t.Errorf("RM schedulable %v, EDF schedulable %v", report.RMSchedulable, report.EDFSchedulable)
+	}
+
+	for _, tt := range []struct {
+		scheduler Scheduler
+		want      map[string]int
+	}{
+		{NewEDFScheduler(), map[string]int{"T1": 0, "T2": 0}},
+		{NewRateMonotonicScheduler(), map[string]int{"T1": 0, "T2": 1}},
+	} {
+		result, err := NewSimulator(WithQuantum(1), WithPreemptOnArrival()).Run(tt.scheduler, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result.DeadlineMisses(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%T: missed %v, want %v", tt.scheduler, got, tt.want)
+		}
+	}
+}
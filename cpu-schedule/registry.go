@@ -0,0 +1,146 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SchedulerOptions configures a scheduler made by MakeScheduler. Schedulers
+// ignore the options that do not apply to them.
+type SchedulerOptions struct {
+	// Quantum is the time slice, in ms, of schedulers that choose their own,
+	// such as the topmost MLFQ queue. Zero means DefaultQuantum.
+	Quantum int64
+	// Queues is the number of MLFQ queues. Zero means 3.
+	Queues int
+	// Seed seeds randomized schedulers such as LOTTERY.
+	Seed int64
+	// TieBreak is used by the schedulers that rank jobs: FIFO, SJF, STCF, RR,
+	// STRIDE, EDF, RM and PRIORITY.
+	TieBreak TieBreak
+	// AgingInterval makes PRIORITY lower the Priority of a waiting job by
+	// one for every AgingInterval ms it waits. Zero disables aging.
+	AgingInterval int64
+	// NonPreemptive makes PRIORITY let the running job keep the CPU until
+	// it finishes or blocks.
+	NonPreemptive bool
+}
+
+// SchedulerFactory creates a new scheduler. Schedulers keep state between
+// calls to Schedule, so every simulation needs its own.
+type SchedulerFactory func(opts SchedulerOptions) (Scheduler, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]SchedulerFactory)
+	// registered keeps the names in registration order
+	registered []string
+)
+
+// RegisterScheduler makes a scheduler available by name to MakeScheduler.
+// Names are case-insensitive. It panics if the name is already registered or
+// factory is nil.
+func RegisterScheduler(name string, factory SchedulerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name = strings.ToUpper(name)
+	if factory == nil {
+		panic("cpu_schedule: RegisterScheduler factory is nil")
+	}
+	if _, ok := registry[name]; ok {
+		panic("cpu_schedule: RegisterScheduler called twice for " + name)
+	}
+	registry[name] = factory
+	registered = append(registered, name)
+}
+
+// SchedulerNames returns the registered scheduler names in the order they
+// were registered, built-in schedulers first.
+func SchedulerNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, len(registered))
+	copy(names, registered)
+	return names
+}
+
+func MakeScheduler(name string, opts SchedulerOptions) (Scheduler, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToUpper(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown scheduler %s", name)
+	}
+	return factory(opts)
+}
+
+func init() {
+	RegisterScheduler("FIFO", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewFIFOScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("SJF", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewSJFScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("STCF", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewSTCFScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("RR", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewRoundRobinScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("MLFQ", func(opts SchedulerOptions) (Scheduler, error) {
+		queues := opts.Queues
+		if queues == 0 {
+			queues = 3
+		}
+		if queues < 0 {
+			return nil, fmt.Errorf("invalid number of queues %d", queues)
+		}
+		quantum := opts.Quantum
+		if quantum == 0 {
+			quantum = DefaultQuantum
+		}
+		return NewMLFQScheduler(MLFQConfig{Queues: queues, Quantums: []int64{quantum}})
+	})
+	RegisterScheduler("LOTTERY", func(opts SchedulerOptions) (Scheduler, error) {
+		return NewLotteryScheduler(opts.Seed), nil
+	})
+	RegisterScheduler("STRIDE", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewStrideScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("EDF", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewEDFScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("RM", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewRateMonotonicScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("PRIORITY", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewPriorityScheduler(PriorityConfig{
+			Preemptive:    !opts.NonPreemptive,
+			AgingInterval: opts.AgingInterval,
+			AgingStep:     1,
+		})
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	RegisterScheduler("CFS", func(opts SchedulerOptions) (Scheduler, error) {
+		return NewCFSScheduler(DefaultCFSConfig)
+	})
+}
@@ -0,0 +1,73 @@
+package cpu_schedule
+
+import (
+	"testing"
+)
+
+// unregister removes a scheduler registered by a test.
+func unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, name)
+	for idx, registeredName := range registered {
+		if registeredName == name {
+			registered = append(registered[:idx], registered[idx+1:]...)
+			break
+		}
+	}
+}
+
+func TestMakeSchedulerIgnoresCase(t *testing.T) {
+	for _, name := range []string{"fifo", "FIFO", "Fifo"} {
+		scheduler, err := MakeScheduler(name, SchedulerOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := scheduler.(*FIFOScheduler); !ok {
+			t.Errorf("MakeScheduler(%q) = %T, want *FIFOScheduler", name, scheduler)
+		}
+	}
+	if _, err := MakeScheduler("no-such-policy", SchedulerOptions{}); err == nil {
+		t.Error("MakeScheduler accepted an unknown name")
+	}
+}
+
+func TestRegisterScheduler(t *testing.T) {
+	RegisterScheduler("test-Custom", func(opts SchedulerOptions) (Scheduler, error) {
+		scheduler := NewSJFScheduler()
+		scheduler.TieBreak = opts.TieBreak
+		return scheduler, nil
+	})
+	t.Cleanup(func() { unregister("TEST-CUSTOM") })
+
+	scheduler, err := MakeScheduler("TEST-custom", SchedulerOptions{TieBreak: TieBreakName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sjf, ok := scheduler.(*SJFScheduler); !ok || sjf.TieBreak != TieBreakName {
+		t.Errorf("MakeScheduler returned %#v, want the registered SJF scheduler", scheduler)
+	}
+	if names := SchedulerNames(); names[len(names)-1] != "TEST-CUSTOM" {
+		t.Errorf("SchedulerNames() = %v, want TEST-CUSTOM last", names)
+	}
+
+	factory := func(opts SchedulerOptions) (Scheduler, error) { return NewFIFOScheduler(), nil }
+	for _, tt := range []struct {
+		name    string
+		factory SchedulerFactory
+	}{
+		{"test-custom", factory},
+		{"fifo", factory},
+		{"test-nil", nil},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterScheduler(%q) did not panic", tt.name)
+				}
+			}()
+			RegisterScheduler(tt.name, tt.factory)
+		}()
+	}
+}
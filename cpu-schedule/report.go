@@ -0,0 +1,106 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TextReporter prints a SimulationResult in the format the simulator used
+// to print directly: one line per job in the order they first ran, followed
+// by the averages. Times are printed in seconds.
+type TextReporter struct {
+	w io.Writer
+}
+
+func NewTextReporter(w io.Writer) *TextReporter {
+	return &TextReporter{w: w}
+}
+
+func (r *TextReporter) Report(result SimulationResult) {
+	jobs := make([]JobResult, len(result.Jobs))
+	copy(jobs, result.Jobs)
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].FirstRun < jobs[j].FirstRun
+	})
+
+	for _, job := range jobs {
+		fmt.Fprintf(r.w, "%s -- Response: %s Turnaround %s Wait %s\n", job.Name,
+			formatSeconds(float64(job.Response)),
+			formatSeconds(float64(job.Turnaround)),
+			formatSeconds(float64(job.Wait)),
+		)
+	}
+
+	fmt.Fprintln(r.w)
+	fmt.Fprintf(r.w, "Average -- Response: %s Turnaround %s Wait %s\n",
+		formatSeconds(result.AverageResponse),
+		formatSeconds(result.AverageTurnaround),
+		formatSeconds(result.AverageWait),
+	)
+	fmt.Fprintf(r.w, "CPU -- Busy: %s Idle %s Utilization %s\n",
+		formatSeconds(float64(result.BusyTime)),
+		formatSeconds(float64(result.IdleTime)),
+		strconv.FormatFloat(result.Utilization*100, 'f', 2, 64)+"%",
+	)
+	for cpu, c := range result.CPUs {
+		fmt.Fprintf(r.w, "CPU %d -- Busy: %s Idle %s Utilization %s\n", cpu,
+			formatSeconds(float64(c.BusyTime)),
+			formatSeconds(float64(c.IdleTime)),
+			strconv.FormatFloat(c.Utilization*100, 'f', 2, 64)+"%",
+		)
+	}
+	if result.Migrations > 0 {
+		fmt.Fprintf(r.w, "Migrations: %d\n", result.Migrations)
+	}
+	starved := make([]string, 0)
+	for _, job := range result.Jobs {
+		if job.Starved {
+			starved = append(starved, job.Name)
+		}
+	}
+	if len(starved) > 0 {
+		fmt.Fprintf(r.w, "Max wait: %s Starved: %s\n", formatSeconds(float64(result.MaxWait)), strings.Join(starved, ", "))
+	} else {
+		fmt.Fprintf(r.w, "Max wait: %s\n", formatSeconds(float64(result.MaxWait)))
+	}
+	if misses := result.DeadlineMisses(); len(misses) > 0 {
+		names := make([]string, 0, len(misses))
+		for name := range misses {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(r.w, "%s -- Deadline misses: %d\n", name, misses[name])
+		}
+	}
+	if result.SwitchOverhead > 0 {
+		fmt.Fprintf(r.w, "Context switches: %d Overhead %s\n",
+			result.ContextSwitches, formatSeconds(float64(result.SwitchOverhead)))
+	}
+}
+
+func formatSeconds(ms float64) string {
+	return strconv.FormatFloat(ms/1000, 'f', 2, 64)
+}
+
+// ReportShares prints each job's share of CPU in windows of the given length,
+// in ms, followed by the unfairness of the run.
+func (r *TextReporter) ReportShares(result SimulationResult, window int64) {
+	names := make([]string, 0, len(result.Jobs))
+	for _, job := range result.Jobs {
+		names = append(names, job.Name)
+	}
+
+	for _, w := range result.CPUShares(window) {
+		fmt.Fprintf(r.w, "[ %s - %s ]", formatSeconds(float64(w.Start)), formatSeconds(float64(w.End)))
+		for _, name := range names {
+			fmt.Fprintf(r.w, " %s: %s", name, strconv.FormatFloat(w.Shares[name]*100, 'f', 1, 64)+"%")
+		}
+		fmt.Fprintln(r.w)
+	}
+
+	fmt.Fprintf(r.w, "Unfairness: %s\n", strconv.FormatFloat(result.Unfairness(), 'f', 2, 64))
+}
@@ -0,0 +1,23 @@
+package cpu_schedule
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReportShares(t *testing.T) {
+	jobs := []Job{{Name: "A", Length: 2000}, {Name: "B", Length: 2000}}
+	result, err := NewSimulator().Run(NewRoundRobinScheduler(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	NewTextReporter(&out).ReportShares(result, 2000)
+	want := "[ 0.00 - 2.00 ] A: 50.0% B: 50.0%\n" +
+		"[ 2.00 - 4.00 ] A: 50.0% B: 50.0%\n" +
+		"Unfairness: 0.75\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,194 @@
+package cpu_schedule
+
+// JobResult holds the statistics of a single job. All times are in ms of
+// simulated time.
+type JobResult struct {
+	Name    string
+	Arrival int64
+	Length  uint64
+	// IO is the time the job spent blocked on I/O.
+	IO int64
+	// FirstRun is the time the job was first scheduled.
+	FirstRun int64
+	// Completion is the time the job finished.
+	Completion int64
+
+	Response   int64
+	Turnaround int64
+	// Wait is the time the job spent ready to run but not running.
+	Wait int64
+	// MaxWait is the longest the job waited for the CPU in one go. Starved
+	// is set when it exceeds the threshold given to WithStarvationThreshold.
+	MaxWait int64
+	Starved bool
+
+	// Task is copied from the job. Deadline is the absolute deadline of jobs
+	// that have one and Missed reports whether the job completed after it.
+	Task     string
+	Deadline int64
+	Missed   bool
+}
+
+// SimulationResult is returned by Simulator.Run. Jobs are listed in the order
+// they were passed to Run.
+type SimulationResult struct {
+	Jobs []JobResult
+
+	AverageResponse   float64
+	AverageTurnaround float64
+	AverageWait       float64
+	// MaxWait is the longest MaxWait of any job.
+	MaxWait int64
+
+	// ContextSwitches counts how often the scheduler picked a different job
+	// than the one that ran last, and SwitchOverhead is the total time, in
+	// ms, spent on those switches.
+	ContextSwitches int
+	SwitchOverhead  int64
+
+	// Runs lists every time slice in the order they ran.
+	Runs []Run
+	// Trace lists what the simulator did, in time order. It is only
+	// recorded by Simulator.
+	Trace []Event
+
+	// TotalTime is when the last job finished. It is split into BusyTime,
+	// when a job was running, IdleTime, when no job was runnable, and
+	// SwitchOverhead.
+	TotalTime   int64
+	BusyTime    int64
+	IdleTime    int64
+	Utilization float64
+
+	// CPUs is only set by MultiSimulator, which also counts how often a job
+	// was migrated between per-CPU queues.
+	CPUs       []CPUResult
+	Migrations int
+}
+
+// Run is a time slice during which Job had the CPU.
+type Run struct {
+	Job   string
+	CPU   int
+	Start int64
+	End   int64
+}
+
+// CPUResult holds the statistics of a single CPU of a MultiSimulator run.
+type CPUResult struct {
+	BusyTime    int64
+	IdleTime    int64
+	Utilization float64
+}
+
+// ShareWindow is the fraction of CPU each job received between Start and End.
+// Jobs that did not run in the window are omitted from Shares.
+type ShareWindow struct {
+	Start  int64
+	End    int64
+	Shares map[string]float64
+}
+
+// CPUShares splits the simulation into windows of the given length, in ms,
+// and reports the share of CPU each job received in every window.
+func (r SimulationResult) CPUShares(window int64) []ShareWindow {
+	if window <= 0 || len(r.Runs) == 0 {
+		return nil
+	}
+
+	end := r.Runs[len(r.Runs)-1].End
+	windows := make([]ShareWindow, 0, end/window+1)
+	for start := int64(0); start < end; start += window {
+		windows = append(windows, ShareWindow{
+			Start:  start,
+			End:    start + window,
+			Shares: make(map[string]float64),
+		})
+	}
+
+	for _, run := range r.Runs {
+		for idx := run.Start / window; idx < int64(len(windows)); idx++ {
+			w := windows[idx]
+			if w.Start >= run.End {
+				break
+			}
+			overlap := min(run.End, w.End) - max(run.Start, w.Start)
+			w.Shares[run.Job] += float64(overlap) / float64(window)
+		}
+	}
+
+	return windows
+}
+
+// Unfairness is the metric from OSTEP chapter 9: the completion time of the
+// first job to finish divided by that of the last, for jobs of equal length.
+// When several groups of equal-length jobs exist the least fair group is
+// reported. A result of 1 means perfectly fair; it is also returned when no
+// two jobs share a length.
+func (r SimulationResult) Unfairness() float64 {
+	first := make(map[uint64]int64)
+	last := make(map[uint64]int64)
+	count := make(map[uint64]int)
+	for _, job := range r.Jobs {
+		if count[job.Length] == 0 || job.Completion < first[job.Length] {
+			first[job.Length] = job.Completion
+		}
+		if count[job.Length] == 0 || job.Completion > last[job.Length] {
+			last[job.Length] = job.Completion
+		}
+		count[job.Length]++
+	}
+
+	unfairness := float64(1)
+	for length, n := range count {
+		if n < 2 || last[length] == 0 {
+			continue
+		}
+		ratio := float64(first[length]) / float64(last[length])
+		if ratio < unfairness {
+			unfairness = ratio
+		}
+	}
+	return unfairness
+}
+
+func (r *SimulationResult) summarize() {
+	if len(r.Jobs) == 0 {
+		return
+	}
+
+	totalResponse := float64(0)
+	totalTurnaround := float64(0)
+	totalWait := float64(0)
+	for _, job := range r.Jobs {
+		totalResponse += float64(job.Response)
+		totalTurnaround += float64(job.Turnaround)
+		totalWait += float64(job.Wait)
+	}
+
+	jobLen := float64(len(r.Jobs))
+	r.AverageResponse = totalResponse / jobLen
+	r.AverageTurnaround = totalTurnaround / jobLen
+	r.AverageWait = totalWait / jobLen
+}
+
+// DeadlineMisses counts the missed deadlines of every task, or of every job
+// that was not released by a periodic task. Tasks and jobs that have a
+// deadline but never missed it are included with a count of zero.
+func (r SimulationResult) DeadlineMisses() map[string]int {
+	misses := make(map[string]int)
+	for _, job := range r.Jobs {
+		if job.Deadline == 0 {
+			continue
+		}
+		name := job.Task
+		if name == "" {
+			name = job.Name
+		}
+		misses[name] += 0
+		if job.Missed {
+			misses[name]++
+		}
+	}
+	return misses
+}
@@ -2,56 +2,182 @@ package cpu_schedule
 
 import (
 	"fmt"
-	"sort"
-	"strconv"
+	"strings"
 )
 
 type Scheduler interface {
 	Schedule(jobs []Job) (Job, error)
 }
 
+// FeedbackScheduler is implemented by schedulers that need to know what
+// happened to the job they picked. After each time slice the Simulator calls
+// Ran with the job, the simulated time at the end of the slice, how long the
+// job actually ran and whether it finished.
+type FeedbackScheduler interface {
+	Scheduler
+	Ran(job Job, now int64, elapsed int64, finished bool)
+}
+
+// TimeSlicer is implemented by schedulers that decide how long the picked job
+// may run. The Simulator uses its own quantum for schedulers that do not.
+type TimeSlicer interface {
+	TimeSlice(job Job) int64
+}
+
+// ArrivalPreempter is implemented by schedulers that must pick again as soon
+// as a job arrives or finishes its I/O. When PreemptOnArrival returns true
+// the Simulator cuts their time slices short as if WithPreemptOnArrival was
+// given.
+type ArrivalPreempter interface {
+	PreemptOnArrival() bool
+}
+
+// TieBreak decides which job a scheduler picks when its policy rates several
+// jobs equally, e.g. two jobs of the same length under SJF.
+type TieBreak uint8
+
+const (
+	// TieBreakInsertion picks the job that comes first in the slice passed
+	// to Schedule. The Simulator passes jobs in arrival order, and jobs that
+	// arrive together in the order they were given to Run.
+	TieBreakInsertion TieBreak = iota
+	// TieBreakArrival picks the job that arrived first, then falls back to
+	// TieBreakInsertion.
+	TieBreakArrival
+	// TieBreakName picks the job whose Name sorts first, then falls back to
+	// TieBreakInsertion.
+	TieBreakName
+)
+
+func ParseTieBreak(name string) (TieBreak, error) {
+	switch strings.ToLower(name) {
+	case "insertion":
+		return TieBreakInsertion, nil
+	case "arrival":
+		return TieBreakArrival, nil
+	case "name":
+		return TieBreakName, nil
+	default:
+		return 0, fmt.Errorf("unknown tie break %s", name)
+	}
+}
+
+// before reports whether a should win a tie against b, where b comes first
+// in the slice passed to Schedule.
+func (t TieBreak) before(a, b Job) bool {
+	switch t {
+	case TieBreakArrival:
+		return a.Arrival < b.Arrival
+	case TieBreakName:
+		return a.Name < b.Name
+	default:
+		return false
+	}
+}
+
+// pickBest returns the job with the lowest key, breaking ties with t.
+func (t TieBreak) pickBest(jobs []Job, key func(job Job) int64) Job {
+	best := jobs[0]
+	bestKey := key(best)
+	for _, job := range jobs[1:] {
+		k := key(job)
+		if k < bestKey || (k == bestKey && t.before(job, best)) {
+			best = job
+			bestKey = k
+		}
+	}
+	return best
+}
+
+// FIFOScheduler runs jobs in the order they arrived.
 type FIFOScheduler struct {
+	TieBreak TieBreak
+	queue    *jobQueue
 }
 
 func NewFIFOScheduler() *FIFOScheduler {
 	return &FIFOScheduler{}
 }
 
-func (f FIFOScheduler) Schedule(jobs []Job) (Job, error) {
+func fifoKey(job Job) int64 {
+	return job.Arrival
+}
+
+func (f *FIFOScheduler) Schedule(jobs []Job) (Job, error) {
 	if len(jobs) == 0 {
 		return Job{}, fmt.Errorf("no jobs provided")
 	}
 
-	return jobs[0], nil
+	return f.TieBreak.pickBest(jobs, fifoKey), nil
 }
 
-type SJFScheduler struct{}
+func (f *FIFOScheduler) ready() *jobQueue {
+	if f.queue == nil {
+		f.queue = newJobQueue(fifoKey, f.TieBreak)
+	}
+	return f.queue
+}
+
+func (f *FIFOScheduler) Enqueue(job Job) { f.ready().push(job) }
+func (f *FIFOScheduler) Dequeue(job Job) { f.ready().remove(job) }
+func (f *FIFOScheduler) Next() (Job, error) {
+	return f.ready().peek()
+}
+
+// SJFScheduler picks the job with the shortest total Length.
+type SJFScheduler struct {
+	TieBreak TieBreak
+	queue    *jobQueue
+}
 
 func NewSJFScheduler() *SJFScheduler {
 	return &SJFScheduler{}
 }
-func (s SJFScheduler) Schedule(jobs []Job) (Job, error) {
+
+func sjfKey(job Job) int64 {
+	return int64(job.Length)
+}
+
+func (s *SJFScheduler) Schedule(jobs []Job) (Job, error) {
 	if len(jobs) == 0 {
 		return Job{}, fmt.Errorf("no jobs provided")
 	}
 
-	var best Job
-	for _, job := range jobs {
-		if best.Length == 0 || job.Length < best.Length {
-			best = job
-		}
+	return s.TieBreak.pickBest(jobs, sjfKey), nil
+}
+
+func (s *SJFScheduler) ready() *jobQueue {
+	if s.queue == nil {
+		s.queue = newJobQueue(sjfKey, s.TieBreak)
 	}
+	return s.queue
+}
 
-	return best, nil
+func (s *SJFScheduler) Enqueue(job Job) { s.ready().push(job) }
+func (s *SJFScheduler) Dequeue(job Job) { s.ready().remove(job) }
+func (s *SJFScheduler) Next() (Job, error) {
+	return s.ready().peek()
 }
 
+// RoundRobinScheduler runs the ready jobs in turn from a FIFO run queue. A
+// job joins the back of the queue when it arrives, when it comes back from
+// I/O and when its time slice ends; jobs that join together are ordered by
+// TieBreak.
 type RoundRobinScheduler struct {
-	runs map[string]int64
+	TieBreak TieBreak
+	// round counts the picks so far. A job's joined is the round it last
+	// joined the queue in, so the queue is ordered by it.
+	round  int64
+	joined map[int]int64
+	// seen is the round after the last one a job was passed to Schedule in
+	seen  map[int]int64
+	queue *jobQueue
 }
 
 func NewRoundRobinScheduler() *RoundRobinScheduler {
 	return &RoundRobinScheduler{
-		runs: make(map[string]int64),
+		joined: make(map[int]int64),
+		seen:   make(map[int]int64),
 	}
 }
 
@@ -60,133 +186,114 @@ func (r *RoundRobinScheduler) Schedule(jobs []Job) (Job, error) {
 		return Job{}, fmt.Errorf("no jobs provided")
 	}
 
-	var best Job
+	// jobs that were not ready last time have just arrived or are back
+	// from I/O
 	for _, job := range jobs {
-		if best.Length == 0 {
-			best = job
-			continue
-		}
-		if r.runs[job.Name] < r.runs[best.Name] {
-			best = job
+		if seen, ok := r.seen[job.ID]; !ok || seen != r.round {
+			r.joined[job.ID] = r.round
 		}
 	}
-
-	r.runs[best.Name] += 1
+	best := r.TieBreak.pickBest(jobs, r.joinedAt)
+	r.round++
+	for _, job := range jobs {
+		r.seen[job.ID] = r.round
+	}
 	return best, nil
 }
 
-type Simulator struct {
+func (r *RoundRobinScheduler) joinedAt(job Job) int64 {
+	return r.joined[job.ID]
 }
 
-func NewSimulator() *Simulator {
-	return &Simulator{}
-}
-
-type Job struct {
-	Name   string
-	Length uint64
+func (r *RoundRobinScheduler) ready() *jobQueue {
+	if r.queue == nil {
+		r.queue = newJobQueue(r.joinedAt, r.TieBreak)
+	}
+	return r.queue
 }
 
-type Jobs struct {
-	jobs []Job
+func (r *RoundRobinScheduler) Enqueue(job Job) {
+	r.joined[job.ID] = r.round
+	r.ready().push(job)
 }
 
-func newJobs(jobs []Job) *Jobs {
-	copied := make([]Job, len(jobs))
-	copy(copied, jobs)
-	return &Jobs{jobs: copied}
+func (r *RoundRobinScheduler) Dequeue(job Job) { r.ready().remove(job) }
+func (r *RoundRobinScheduler) Next() (Job, error) {
+	best, err := r.ready().peek()
+	if err != nil {
+		return Job{}, err
+	}
+	r.round++
+	return best, nil
 }
 
-func (j *Jobs) remove(name string) {
-	idx := -1
-	for i, job := range j.jobs {
-		if job.Name == name {
-			idx = i
-		}
+// Ran puts the job at the back of the queue once its time slice is over.
+func (r *RoundRobinScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	if finished {
+		delete(r.joined, job.ID)
+		delete(r.seen, job.ID)
+		return
 	}
-	if idx != -1 {
-		j.jobs = append(j.jobs[:idx], j.jobs[idx+1:]...)
+	r.joined[job.ID] = r.round
+	if r.queue != nil {
+		r.queue.fix(job)
 	}
 }
 
-func (j *Jobs) value() []Job {
-	return j.jobs
-}
-func (j *Jobs) isEmpty() bool {
-	return len(j.jobs) == 0
+// STCFScheduler is Shortest-Time-to-Completion-First: it picks the job with
+// the least remaining work and lets it run until it finishes, or until a
+// job arrives or comes back from I/O: it implements ArrivalPreempter, so a
+// shorter newcomer preempts the running job.
+type STCFScheduler struct {
+	TieBreak TieBreak
+	executed map[int]int64
+	queue    *jobQueue
 }
 
-func (s *Simulator) Run(scheduler Scheduler, jobs []Job) {
-	js := newJobs(jobs)
-
-	currentTimeInMs := int64(0)
-	step := int64(1000)
-	arrivals := make(map[string]int64)
-	executeds := make(map[string]int64)
-	firstSchedules := make(map[string]int64)
-	completedAts := make(map[string]int64)
-
-	for _, job := range js.value() {
-		arrivals[job.Name] = currentTimeInMs
-		executeds[job.Name] = 0
-		firstSchedules[job.Name] = -1
-		completedAts[job.Name] = -1
+func NewSTCFScheduler() *STCFScheduler {
+	return &STCFScheduler{
+		executed: make(map[int]int64),
 	}
+}
 
-	for !js.isEmpty() {
-		job, err := scheduler.Schedule(js.value())
-		if err != nil {
-			panic(err)
-		}
-
-		if firstSchedules[job.Name] == -1 {
-			firstSchedules[job.Name] = currentTimeInMs
-		}
-		executeds[job.Name] += step
+func (s *STCFScheduler) remaining(job Job) int64 {
+	return int64(job.Length) - s.executed[job.ID]
+}
 
-		currentTimeInMs = currentTimeInMs + step
-		if executeds[job.Name] >= int64(job.Length) {
-			completedAts[job.Name] = currentTimeInMs
-			js.remove(job.Name)
-		}
+func (s *STCFScheduler) Schedule(jobs []Job) (Job, error) {
+	if len(jobs) == 0 {
+		return Job{}, fmt.Errorf("no jobs provided")
 	}
 
-	// print stats
-	totalResponse := float64(0)
-	totalTurnaround := float64(0)
-	totalWait := float64(0)
-
-	sort.Slice(jobs, func(i, j int) bool {
-		return firstSchedules[jobs[i].Name] < firstSchedules[jobs[j].Name]
-	})
-	for _, job := range jobs {
-		response := float64(firstSchedules[job.Name]) / 1000
-		turnaround := float64(completedAts[job.Name]) / 1000
-		wait := turnaround - (float64(arrivals[job.Name]) / 1000) - (float64(job.Length / 1000))
+	return s.TieBreak.pickBest(jobs, s.remaining), nil
+}
 
-		fmt.Printf("%s -- Response: %s Turnaround %s Wait %s\n", job.Name,
-			strconv.FormatFloat(response, 'f', 2, 64),
-			strconv.FormatFloat(turnaround, 'f', 2, 64),
-			strconv.FormatFloat(wait, 'f', 2, 64),
-		)
-		totalResponse += response
-		totalTurnaround += turnaround
-		totalWait += wait
+func (s *STCFScheduler) ready() *jobQueue {
+	if s.queue == nil {
+		s.queue = newJobQueue(s.remaining, s.TieBreak)
 	}
+	return s.queue
+}
 
-	fmt.Println()
-	jobLen := float64(len(jobs))
-	fmt.Printf("Average -- Response: %s Turnaround %s Wait %s\n",
-		strconv.FormatFloat(totalResponse/jobLen, 'f', 2, 64),
-		strconv.FormatFloat(totalTurnaround/jobLen, 'f', 2, 64),
-		strconv.FormatFloat(totalWait/jobLen, 'f', 2, 64),
-	)
-
+func (s *STCFScheduler) Enqueue(job Job) { s.ready().push(job) }
+func (s *STCFScheduler) Dequeue(job Job) { s.ready().remove(job) }
+func (s *STCFScheduler) Next() (Job, error) {
+	return s.ready().peek()
 }
 
-type Simulation struct {
-	currentTimeInMs uint64
-	jobs            []Job
+func (s *STCFScheduler) PreemptOnArrival() bool { return true }
+
+func (s *STCFScheduler) TimeSlice(job Job) int64 {
+	return s.remaining(job)
 }
 
-// create a scheduler (either FIFO, SJF or RR), a simulator and share the final result
+func (s *STCFScheduler) Ran(job Job, now int64, elapsed int64, finished bool) {
+	if finished {
+		delete(s.executed, job.ID)
+		return
+	}
+	s.executed[job.ID] += elapsed
+	if s.queue != nil {
+		s.queue.fix(job)
+	}
+}
@@ -0,0 +1,151 @@
+package cpu_schedule
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// tieJobs is full of ties: equal lengths, equal arrivals and names that are
+// not in input order.
+var tieJobs = []Job{
+	{Name: "C", Arrival: 0, Length: 2000},
+	{Name: "A", Arrival: 1000, Length: 2000},
+	{Name: "B", Arrival: 0, Length: 2000},
+	{Name: "D", Arrival: 1000, Length: 1000},
+}
+
+func TestScheduleGolden(t *testing.T) {
+	for _, policy := range []string{"FIFO", "SJF", "STCF", "RR", "STRIDE"} {
+		for _, tieBreak := range []string{"insertion", "arrival", "name"} {
+			name := policy + "_" + tieBreak
+			t.Run(name, func(t *testing.T) {
+				tb, err := ParseTieBreak(tieBreak)
+				if err != nil {
+					t.Fatal(err)
+				}
+				scheduler, err := MakeScheduler(policy, SchedulerOptions{TieBreak: tb})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				result, err := NewSimulator(WithPreemptOnArrival()).Run(scheduler, tieJobs)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var out bytes.Buffer
+				if err := RenderGantt(&out, result, DefaultQuantum); err != nil {
+					t.Fatal(err)
+				}
+				if err := WriteTraceCSV(&out, result); err != nil {
+					t.Fatal(err)
+				}
+				NewTextReporter(&out).Report(result)
+
+				checkGolden(t, filepath.Join("testdata", name+".golden"), out.Bytes())
+			})
+		}
+	}
+}
+
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s, run go test -update to accept it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestRunDoesNotModifyJobs(t *testing.T) {
+	jobs := make([]Job, len(tieJobs))
+	copy(jobs, tieJobs)
+
+	for _, policy := range SchedulerNames() {
+		scheduler, err := MakeScheduler(policy, SchedulerOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := NewSimulator().Run(scheduler, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		NewTextReporter(&bytes.Buffer{}).Report(result)
+
+		if !reflect.DeepEqual(jobs, tieJobs) {
+			t.Fatalf("%s: Run modified its input: got %v, want %v", policy, jobs, tieJobs)
+		}
+	}
+}
+
+func TestParseTieBreak(t *testing.T) {
+	for name, want := range map[string]TieBreak{
+		"insertion": TieBreakInsertion,
+		"arrival":   TieBreakArrival,
+		"Name":      TieBreakName,
+	} {
+		got, err := ParseTieBreak(name)
+		if err != nil || got != want {
+			t.Errorf("ParseTieBreak(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseTieBreak("random"); err == nil {
+		t.Error("ParseTieBreak(\"random\") should fail")
+	}
+}
+
+func TestRoundRobinQueuesLateArrivalsAtTheBack(t *testing.T) {
+	// B arrives halfway through A and takes turns with it instead of running
+	// until it has been scheduled as often as A
+	jobs := []Job{
+		{Name: "A", Length: 10000},
+		{Name: "B", Arrival: 5000, Length: 5000},
+	}
+	for _, scheduler := range []Scheduler{NewRoundRobinScheduler(), hideQueue(NewRoundRobinScheduler())} {
+		result, err := NewSimulator().Run(scheduler, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for idx, want := range []int64{14000, 15000} {
+			if got := result.Jobs[idx].Completion; got != want {
+				t.Errorf("%T: %s completed at %d, want %d", scheduler, result.Jobs[idx].Name, got, want)
+			}
+		}
+		if got := result.Jobs[1].Response; got != 1000 {
+			t.Errorf("%T: B response %d, want 1000", scheduler, got)
+		}
+	}
+}
+
+func TestSTCFPreemptsWithoutOption(t *testing.T) {
+	jobs := []Job{
+		{Name: "A", Length: 10000},
+		{Name: "B", Arrival: 1000, Length: 2000},
+	}
+	scheduler, err := MakeScheduler("STCF", SchedulerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewSimulator().Run(scheduler, jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := result.Jobs[1]; b.Response != 0 || b.Completion != 3000 {
+		t.Errorf("B responded after %d and completed at %d, want 0 and 3000", b.Response, b.Completion)
+	}
+}
@@ -0,0 +1,515 @@
+package cpu_schedule
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const DefaultQuantum = int64(1000)
+
+type Simulator struct {
+	quantum           int64
+	contextSwitchCost int64
+	preemptOnArrival  bool
+	starvation        int64
+}
+
+// SimulatorOption configures a Simulator created by NewSimulator.
+type SimulatorOption func(s *Simulator)
+
+// WithQuantum sets the length, in ms, of the time slice a job runs for each
+// time it is scheduled. It defaults to DefaultQuantum; Run fails if it is not
+// positive.
+func WithQuantum(quantum int64) SimulatorOption {
+	return func(s *Simulator) {
+		s.quantum = quantum
+	}
+}
+
+// WithContextSwitchCost sets the overhead, in ms, charged whenever the
+// scheduler picks a different job than the one that ran last.
+func WithContextSwitchCost(cost int64) SimulatorOption {
+	return func(s *Simulator) {
+		s.contextSwitchCost = cost
+	}
+}
+
+// WithPreemptOnArrival cuts the running time slice short whenever a job
+// arrives or finishes its I/O, so the scheduler can pick again straight away.
+// Schedulers that implement ArrivalPreempter, such as STCFScheduler, get this
+// behaviour without it.
+func WithPreemptOnArrival() SimulatorOption {
+	return func(s *Simulator) {
+		s.preemptOnArrival = true
+	}
+}
+
+// WithStarvationThreshold flags a job as starved when it once waited longer
+// than threshold ms for the CPU while it was ready to run.
+func WithStarvationThreshold(threshold int64) SimulatorOption {
+	return func(s *Simulator) {
+		s.starvation = threshold
+	}
+}
+
+func NewSimulator(opts ...SimulatorOption) *Simulator {
+	s := &Simulator{quantum: DefaultQuantum}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type Job struct {
+	// ID is assigned by the Simulator when the job is run; any value set by
+	// the caller is ignored.
+	ID   int
+	Name string
+	// Arrival is the simulated time, in ms, at which the job becomes
+	// runnable.
+	Arrival int64
+	Length  uint64
+	// Tickets is the job's share for the proportional-share schedulers. Zero
+	// means DefaultTickets; more than MaxTickets is rejected.
+	Tickets uint64
+	// Nice is the job's nice value for CFSScheduler, from -20 (highest
+	// priority) to 19 (lowest).
+	Nice int
+	// Priority is the job's priority; lower values are more important.
+	Priority int
+	// Period is how often, in ms, a periodic task releases a new job. See
+	// ExpandPeriodic.
+	Period int64
+	// Deadline is the time, in ms after Arrival, by which the job should be
+	// done. Zero means the end of its Period, or no deadline at all for jobs
+	// without a period.
+	Deadline int64
+	// Task names the periodic task a job was released by. ExpandPeriodic
+	// sets it.
+	Task string
+	// Bursts describes a job that alternates between using the CPU and
+	// waiting for I/O. When set, Length is the sum of the CPU bursts. The I/O
+	// of the last burst is ignored since the job is done by then.
+	Bursts []Burst
+}
+
+// Burst is a stretch of CPU work followed by an I/O wait, both in ms. While
+// waiting for I/O the job is blocked and other jobs can use the CPU.
+type Burst struct {
+	CPU int64
+	IO  int64
+}
+
+func (j Job) bursts() []Burst {
+	if len(j.Bursts) == 0 {
+		return []Burst{{CPU: int64(j.Length)}}
+	}
+	return j.Bursts
+}
+
+// ioLength is the total time the job spends blocked on I/O.
+func (j Job) ioLength() int64 {
+	bursts := j.bursts()
+	total := int64(0)
+	for _, burst := range bursts[:len(bursts)-1] {
+		total += burst.IO
+	}
+	return total
+}
+
+const DefaultTickets = uint64(100)
+
+// MaxTickets is the most tickets a job may hold.
+const MaxTickets = uint64(1) << 20
+
+func (j Job) tickets() uint64 {
+	if j.Tickets == 0 {
+		return DefaultTickets
+	}
+	return j.Tickets
+}
+
+// ValidateJobs checks that every job has a unique, non-empty Name, no
+// negative times and a Length that fits in an int64. Results, traces and reports identify jobs by Name, so Run
+// rejects jobs that fail it; see RenameDuplicates.
+func ValidateJobs(jobs []Job) error {
+	seen := make(map[string]int, len(jobs))
+	for idx, job := range jobs {
+		if job.Name == "" {
+			return fmt.Errorf("job %d has no name", idx)
+		}
+		if first, ok := seen[job.Name]; ok {
+			return fmt.Errorf("jobs %d and %d are both named %q", first, idx, job.Name)
+		}
+		seen[job.Name] = idx
+		if job.Tickets > MaxTickets {
+			return fmt.Errorf("job %s has %d tickets, more than %d", job.Name, job.Tickets, MaxTickets)
+		}
+		if job.Arrival < 0 {
+			return fmt.Errorf("job %s arrives at negative time %d", job.Name, job.Arrival)
+		}
+		if len(job.Bursts) == 0 && job.Length > math.MaxInt64 {
+			return fmt.Errorf("job %s is too long: %d", job.Name, job.Length)
+		}
+		for _, burst := range job.Bursts {
+			if burst.CPU < 0 || burst.IO < 0 {
+				return fmt.Errorf("job %s has a negative burst", job.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// RenameDuplicates returns a copy of jobs in which every job that reuses an
+// earlier job's Name gets a ".n" suffix, n counting from 2, that is not
+// taken by any other job.
+func RenameDuplicates(jobs []Job) []Job {
+	taken := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		taken[job.Name] = true
+	}
+
+	renamed := make([]Job, len(jobs))
+	seen := make(map[string]int, len(jobs))
+	for idx, job := range jobs {
+		seen[job.Name]++
+		if seen[job.Name] > 1 {
+			name := job.Name
+			for n := seen[job.Name]; taken[job.Name]; n++ {
+				job.Name = fmt.Sprintf("%s.%d", name, n)
+			}
+			taken[job.Name] = true
+		}
+		renamed[idx] = job
+	}
+	return renamed
+}
+
+// prepareJobs validates and copies jobs, fills in Length for jobs with bursts
+// and sorts them by arrival, keeping the input order of jobs that arrive
+// together. Every job's ID is its position in the sorted slice; ids[i] is the
+// ID of jobs[i].
+func prepareJobs(jobs []Job) (sorted []Job, ids []int, err error) {
+	if err := ValidateJobs(jobs); err != nil {
+		return nil, nil, err
+	}
+
+	order := make([]int, len(jobs))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return jobs[order[i]].Arrival < jobs[order[j]].Arrival
+	})
+
+	sorted = make([]Job, len(jobs))
+	ids = make([]int, len(jobs))
+	for id, idx := range order {
+		job := jobs[idx]
+		if len(job.Bursts) > 0 {
+			job.Length = 0
+			for _, burst := range job.Bursts {
+				job.Length += uint64(burst.CPU)
+			}
+		}
+		job.ID = id
+		sorted[id] = job
+		ids[idx] = id
+	}
+	return sorted, ids, nil
+}
+
+// simJob is the Simulator's bookkeeping for one job.
+type simJob struct {
+	executed      int64
+	burstIdx      int
+	burstExecuted int64
+	firstRun      int64
+	completion    int64
+	readySince    int64
+	maxWait       int64
+	ready         bool
+}
+
+// readyList holds the ready jobs in ID order for schedulers that are not a
+// QueueScheduler, so the same slice can be passed to every Schedule call.
+type readyList struct {
+	jobs []Job
+}
+
+func (l *readyList) search(id int) int {
+	return sort.Search(len(l.jobs), func(i int) bool {
+		return l.jobs[i].ID >= id
+	})
+}
+
+func (l *readyList) insert(job Job) {
+	idx := l.search(job.ID)
+	l.jobs = append(l.jobs, Job{})
+	copy(l.jobs[idx+1:], l.jobs[idx:])
+	l.jobs[idx] = job
+}
+
+func (l *readyList) remove(job Job) {
+	idx := l.search(job.ID)
+	if idx < len(l.jobs) && l.jobs[idx].ID == job.ID {
+		l.jobs = append(l.jobs[:idx], l.jobs[idx+1:]...)
+	}
+}
+
+// wakeup is a job blocked on I/O until at.
+type wakeup struct {
+	at int64
+	id int
+}
+
+// wakeups is a min-heap of blocked jobs by the time their I/O completes.
+type wakeups []wakeup
+
+func (w wakeups) Len() int { return len(w) }
+func (w wakeups) Less(i, j int) bool {
+	if w[i].at != w[j].at {
+		return w[i].at < w[j].at
+	}
+	return w[i].id < w[j].id
+}
+func (w wakeups) Swap(i, j int) { w[i], w[j] = w[j], w[i] }
+func (w *wakeups) Push(x any)   { *w = append(*w, x.(wakeup)) }
+func (w *wakeups) Pop() any {
+	old := *w
+	last := old[len(old)-1]
+	*w = old[:len(old)-1]
+	return last
+}
+
+// Run simulates scheduling jobs on a single CPU and returns the per-job
+// statistics. The jobs slice is not modified. It fails if the quantum is not
+// positive, the jobs do not pass ValidateJobs or the scheduler returns an
+// error, a job that is not ready to run or a time slice that is not positive.
+//
+// Run is event driven: when no job is runnable it jumps straight to the next
+// arrival or I/O completion, blocked jobs wait in a heap and the ready jobs
+// are kept up to date as jobs come and go rather than recomputed for every
+// time slice. Schedulers that implement QueueScheduler keep their own ready
+// queue; all others get the ready jobs, in ID order, passed to Schedule.
+// Schedulers must not modify or keep that slice.
+func (s *Simulator) Run(scheduler Scheduler, jobs []Job) (SimulationResult, error) {
+	if s.quantum <= 0 {
+		return SimulationResult{}, fmt.Errorf("invalid quantum %d", s.quantum)
+	}
+	if s.contextSwitchCost < 0 {
+		return SimulationResult{}, fmt.Errorf("invalid context switch cost %d", s.contextSwitchCost)
+	}
+	sorted, ids, err := prepareJobs(jobs)
+	if err != nil {
+		return SimulationResult{}, err
+	}
+	states := make([]simJob, len(sorted))
+	for id, job := range sorted {
+		states[id] = simJob{firstRun: -1, completion: -1, readySince: job.Arrival}
+	}
+
+	queue, _ := scheduler.(QueueScheduler)
+	feedback, _ := scheduler.(FeedbackScheduler)
+	slicer, _ := scheduler.(TimeSlicer)
+	preempt := s.preemptOnArrival
+	if preempter, ok := scheduler.(ArrivalPreempter); ok && preempter.PreemptOnArrival() {
+		preempt = true
+	}
+	ready := &readyList{}
+	readyCount := 0
+	setReady := func(id int, isReady bool) {
+		states[id].ready = isReady
+		switch {
+		case queue != nil && isReady:
+			queue.Enqueue(sorted[id])
+		case queue != nil:
+			queue.Dequeue(sorted[id])
+		case isReady:
+			ready.insert(sorted[id])
+		default:
+			ready.remove(sorted[id])
+		}
+		if isReady {
+			readyCount++
+		} else {
+			readyCount--
+		}
+	}
+
+	blocked := &wakeups{}
+	nextArrival := 0
+	// nextEvent returns the earliest time at which a job arrives or finishes
+	// its I/O, or -1 if there is none
+	nextEvent := func() int64 {
+		next := int64(-1)
+		if nextArrival < len(sorted) {
+			next = sorted[nextArrival].Arrival
+		}
+		if blocked.Len() > 0 && (next == -1 || (*blocked)[0].at < next) {
+			next = (*blocked)[0].at
+		}
+		return next
+	}
+
+	currentTimeInMs := int64(0)
+	remaining := len(sorted)
+	lastJob := -1
+	running := -1
+	switches := 0
+	switchOverhead := int64(0)
+	busy := int64(0)
+	runs := make([]Run, 0)
+	trace := make([]Event, 0)
+
+	for remaining > 0 {
+		for nextArrival < len(sorted) && sorted[nextArrival].Arrival <= currentTimeInMs {
+			setReady(nextArrival, true)
+			nextArrival++
+		}
+		// wake up the jobs whose I/O has completed
+		for blocked.Len() > 0 && (*blocked)[0].at <= currentTimeInMs {
+			w := heap.Pop(blocked).(wakeup)
+			states[w.id].readySince = w.at
+			setReady(w.id, true)
+		}
+
+		if readyCount == 0 {
+			// nothing is runnable, so the CPU idles until a job arrives or
+			// finishes its I/O
+			next := nextEvent()
+			trace = append(trace, Event{Time: currentTimeInMs, Kind: EventIdle, Duration: next - currentTimeInMs})
+			currentTimeInMs = next
+			continue
+		}
+
+		var picked Job
+		if queue != nil {
+			picked, err = queue.Next()
+		} else {
+			picked, err = scheduler.Schedule(ready.jobs)
+		}
+		if err != nil {
+			return SimulationResult{}, fmt.Errorf("scheduling at %dms: %w", currentTimeInMs, err)
+		}
+		id := picked.ID
+		if id < 0 || id >= len(sorted) || !states[id].ready || sorted[id].Name != picked.Name {
+			return SimulationResult{}, fmt.Errorf("scheduling at %dms: picked %s which is not ready", currentTimeInMs, picked.Name)
+		}
+		job := sorted[id]
+		state := &states[id]
+
+		if running != -1 && running != id {
+			trace = append(trace, Event{Time: currentTimeInMs, Kind: EventPreempt, Job: sorted[running].Name})
+		}
+		if lastJob != -1 && lastJob != id {
+			switches++
+			switchOverhead += s.contextSwitchCost
+			currentTimeInMs += s.contextSwitchCost
+		}
+		lastJob = id
+		if running != id {
+			trace = append(trace, Event{Time: currentTimeInMs, Kind: EventSchedule, Job: job.Name})
+			running = id
+		}
+
+		if state.firstRun == -1 {
+			state.firstRun = currentTimeInMs
+		}
+		state.maxWait = max(state.maxWait, currentTimeInMs-state.readySince)
+		step := s.quantum
+		if slicer != nil {
+			step = slicer.TimeSlice(job)
+		}
+		if preempt {
+			// a job that arrived during the context switch is only seen
+			// once this slice is over, so only later events cut it short
+			if next := nextEvent(); next > currentTimeInMs && next-currentTimeInMs < step {
+				step = next - currentTimeInMs
+			}
+		}
+		bursts := job.bursts()
+		burst := bursts[state.burstIdx]
+		left := burst.CPU - state.burstExecuted
+		if step <= 0 && left > 0 {
+			// the job would never make progress
+			return SimulationResult{}, fmt.Errorf("scheduling at %dms: invalid time slice %d for %s", currentTimeInMs, step, job.Name)
+		}
+		if left < step {
+			step = left
+		}
+		state.executed += step
+		state.burstExecuted += step
+		busy += step
+		runs = append(runs, Run{Job: job.Name, Start: currentTimeInMs, End: currentTimeInMs + step})
+
+		currentTimeInMs = currentTimeInMs + step
+		finished := false
+		state.readySince = currentTimeInMs
+		if state.burstExecuted >= burst.CPU {
+			if state.burstIdx == len(bursts)-1 {
+				finished = true
+				state.completion = currentTimeInMs
+				setReady(id, false)
+				remaining--
+				trace = append(trace, Event{Time: currentTimeInMs, Kind: EventComplete, Job: job.Name})
+				running = -1
+			} else {
+				state.burstIdx++
+				state.burstExecuted = 0
+				if burst.IO > 0 {
+					setReady(id, false)
+					heap.Push(blocked, wakeup{at: currentTimeInMs + burst.IO, id: id})
+					trace = append(trace, Event{Time: currentTimeInMs, Kind: EventBlock, Job: job.Name, Duration: burst.IO})
+					running = -1
+				}
+			}
+		}
+		if feedback != nil {
+			feedback.Ran(job, currentTimeInMs, step, finished)
+		}
+	}
+
+	result := SimulationResult{
+		Jobs:            make([]JobResult, 0, len(jobs)),
+		ContextSwitches: switches,
+		SwitchOverhead:  switchOverhead,
+		Runs:            runs,
+		Trace:           trace,
+		TotalTime:       currentTimeInMs,
+		BusyTime:        busy,
+		IdleTime:        currentTimeInMs - busy - switchOverhead,
+	}
+	if currentTimeInMs > 0 {
+		result.Utilization = float64(busy) / float64(currentTimeInMs)
+	}
+	for _, id := range ids {
+		job := sorted[id]
+		state := states[id]
+		jr := JobResult{
+			Name:       job.Name,
+			Arrival:    job.Arrival,
+			Length:     uint64(state.executed),
+			IO:         job.ioLength(),
+			FirstRun:   state.firstRun,
+			Completion: state.completion,
+		}
+		jr.Response = jr.FirstRun - jr.Arrival
+		jr.Turnaround = jr.Completion - jr.Arrival
+		jr.Wait = jr.Turnaround - int64(jr.Length) - jr.IO
+		jr.Task = job.Task
+		jr.MaxWait = state.maxWait
+		jr.Starved = s.starvation > 0 && jr.MaxWait > s.starvation
+		result.MaxWait = max(result.MaxWait, jr.MaxWait)
+		if deadline, ok := job.absoluteDeadline(); ok {
+			jr.Deadline = deadline
+			jr.Missed = jr.Completion > deadline
+		}
+		result.Jobs = append(result.Jobs, jr)
+	}
+	result.summarize()
+
+	return result, nil
+}
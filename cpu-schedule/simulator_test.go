@@ -0,0 +1,340 @@
+package cpu_schedule
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// sliceOnly hides a scheduler's QueueScheduler methods so the Simulator
+// passes it the ready slice on every pick. Use hideQueue to keep the
+// scheduler's other optional methods.
+type sliceOnly struct {
+	scheduler Scheduler
+}
+
+func (s sliceOnly) Schedule(jobs []Job) (Job, error) {
+	return s.scheduler.Schedule(jobs)
+}
+
+func (s sliceOnly) PreemptOnArrival() bool {
+	preempter, ok := s.scheduler.(ArrivalPreempter)
+	return ok && preempter.PreemptOnArrival()
+}
+
+type sliceOnlyFeedback struct {
+	sliceOnly
+}
+
+func (s sliceOnlyFeedback) Ran(job Job, now int64, elapsed int64, finished bool) {
+	s.scheduler.(FeedbackScheduler).Ran(job, now, elapsed, finished)
+}
+
+type sliceOnlySlicer struct {
+	sliceOnlyFeedback
+}
+
+func (s sliceOnlySlicer) TimeSlice(job Job) int64 {
+	return s.scheduler.(TimeSlicer).TimeSlice(job)
+}
+
+// hideQueue wraps scheduler in the sliceOnly type that keeps its Ran and
+// TimeSlice methods.
+func hideQueue(scheduler Scheduler) Scheduler {
+	_, feedback := scheduler.(FeedbackScheduler)
+	_, slicer := scheduler.(TimeSlicer)
+	switch {
+	case feedback && slicer:
+		return sliceOnlySlicer{sliceOnlyFeedback{sliceOnly{scheduler}}}
+	case feedback:
+		return sliceOnlyFeedback{sliceOnly{scheduler}}
+	case slicer:
+		panic("hideQueue: TimeSlicer without FeedbackScheduler")
+	}
+	return sliceOnly{scheduler}
+}
+
+// benchJobs returns count jobs with staggered arrivals and a mix of CPU and
+// I/O bursts, so the ready queue both grows and shrinks during a run.
+func benchJobs(count int) []Job {
+	random := rand.New(rand.NewSource(1))
+	jobs := make([]Job, count)
+	var arrival int64
+	for i := range jobs {
+		arrival += random.Int63n(500)
+		jobs[i] = Job{
+			Name:    fmt.Sprintf("job%d", i),
+			Arrival: arrival,
+			Length:  uint64(1 + random.Int63n(5000)),
+		}
+		if i%4 == 0 {
+			jobs[i].Bursts = []Burst{
+				{CPU: 1 + random.Int63n(2000), IO: 1 + random.Int63n(3000)},
+				{CPU: 1 + random.Int63n(2000)},
+			}
+		}
+	}
+	return jobs
+}
+
+func TestQueueMatchesSlice(t *testing.T) {
+	jobs := benchJobs(500)
+	for _, policy := range []string{"FIFO", "SJF", "STCF", "RR", "EDF", "RM", "STRIDE"} {
+		for _, tieBreak := range []TieBreak{TieBreakInsertion, TieBreakArrival, TieBreakName} {
+			checkQueueMatchesSlice(t, policy, SchedulerOptions{TieBreak: tieBreak}, jobs)
+		}
+	}
+	// CFS and MLFQ ignore the tie-break
+	for _, policy := range []string{"CFS", "MLFQ"} {
+		checkQueueMatchesSlice(t, policy, SchedulerOptions{}, jobs)
+	}
+}
+
+func checkQueueMatchesSlice(t *testing.T, policy string, options SchedulerOptions, jobs []Job) {
+	t.Helper()
+	queued, err := MakeScheduler(policy, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := queued.(QueueScheduler); !ok {
+		t.Fatalf("%s does not implement QueueScheduler", policy)
+	}
+	sliced, err := MakeScheduler(policy, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := NewSimulator(WithPreemptOnArrival()).Run(hideQueue(sliced), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewSimulator(WithPreemptOnArrival()).Run(queued, jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s/%d: queued run differs from the sliced run", policy, options.TieBreak)
+	}
+}
+
+// legacyRun is the per-tick loop Run used before it became event driven: on
+// every time slice it rebuilds the ready jobs from all unfinished jobs and
+// scans every blocked job. It only returns the completion time of each job,
+// in the order of jobs, and is kept to measure Run against.
+func legacyRun(scheduler Scheduler, jobs []Job, quantum int64) ([]int64, error) {
+	sorted, ids, err := prepareJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+	pending := append([]Job(nil), sorted...)
+	burstIdxs := make([]int, len(sorted))
+	burstExecuteds := make([]int64, len(sorted))
+	completions := make([]int64, len(sorted))
+	blocked := make(map[int]int64)
+	feedback, _ := scheduler.(FeedbackScheduler)
+
+	now := int64(0)
+	for len(pending) > 0 {
+		for id, wakeAt := range blocked {
+			if wakeAt <= now {
+				delete(blocked, id)
+			}
+		}
+		ready := make([]Job, 0)
+		next := int64(-1)
+		for _, job := range pending {
+			at := job.Arrival
+			if wakeAt, ok := blocked[job.ID]; ok {
+				at = max(at, wakeAt)
+			}
+			if at <= now {
+				ready = append(ready, job)
+			} else if next == -1 || at < next {
+				next = at
+			}
+		}
+		if len(ready) == 0 {
+			now = next
+			continue
+		}
+
+		job, err := scheduler.Schedule(ready)
+		if err != nil {
+			return nil, err
+		}
+		bursts := job.bursts()
+		burst := bursts[burstIdxs[job.ID]]
+		step := min(quantum, burst.CPU-burstExecuteds[job.ID])
+		burstExecuteds[job.ID] += step
+		now += step
+
+		finished := false
+		if burstExecuteds[job.ID] >= burst.CPU {
+			if burstIdxs[job.ID] == len(bursts)-1 {
+				finished = true
+				completions[job.ID] = now
+				for idx := range pending {
+					if pending[idx].ID == job.ID {
+						pending = append(pending[:idx], pending[idx+1:]...)
+						break
+					}
+				}
+			} else {
+				burstIdxs[job.ID]++
+				burstExecuteds[job.ID] = 0
+				if burst.IO > 0 {
+					blocked[job.ID] = now + burst.IO
+				}
+			}
+		}
+		if feedback != nil {
+			feedback.Ran(job, now, step, finished)
+		}
+	}
+
+	byInput := make([]int64, len(jobs))
+	for idx, id := range ids {
+		byInput[idx] = completions[id]
+	}
+	return byInput, nil
+}
+
+func TestLegacyRunMatchesRun(t *testing.T) {
+	jobs := benchJobs(300)
+	want, err := legacyRun(NewSJFScheduler(), jobs, DefaultQuantum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewSimulator().Run(NewSJFScheduler(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, job := range result.Jobs {
+		if job.Completion != want[idx] {
+			t.Errorf("%s completed at %d, the per-tick loop at %d", job.Name, job.Completion, want[idx])
+		}
+	}
+}
+
+func benchmarkLegacyRun(b *testing.B, count int) {
+	jobs := benchJobs(count)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyRun(NewSJFScheduler(), jobs, DefaultQuantum); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// the per-tick loop takes tens of seconds for 10k jobs
+func BenchmarkRunLegacy1k(b *testing.B) { benchmarkLegacyRun(b, 1000) }
+
+func benchmarkRun(b *testing.B, count int, queued bool) {
+	jobs := benchJobs(count)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var scheduler Scheduler = NewSJFScheduler()
+		if !queued {
+			scheduler = hideQueue(scheduler)
+		}
+		if _, err := NewSimulator().Run(scheduler, jobs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRunSlice1k(b *testing.B)   { benchmarkRun(b, 1000, false) }
+func BenchmarkRunSlice10k(b *testing.B)  { benchmarkRun(b, 10000, false) }
+func BenchmarkRunQueue1k(b *testing.B)   { benchmarkRun(b, 1000, true) }
+func BenchmarkRunQueue10k(b *testing.B)  { benchmarkRun(b, 10000, true) }
+func BenchmarkRunQueue100k(b *testing.B) { benchmarkRun(b, 100000, true) }
+
+func TestRunRejectsDuplicateNames(t *testing.T) {
+	jobs := []Job{
+		{Name: "A", Length: 1000},
+		{Name: "B", Length: 2000},
+		{Name: "A", Length: 3000},
+	}
+	if _, err := NewSimulator().Run(NewFIFOScheduler(), jobs); err == nil {
+		t.Fatal("Run accepted two jobs named A")
+	}
+
+	result, err := NewSimulator().Run(NewRoundRobinScheduler(), RenameDuplicates(jobs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, job := range result.Jobs {
+		names = append(names, job.Name)
+		if job.Length != jobs[len(names)-1].Length {
+			t.Errorf("%s ran for %d, want %d", job.Name, job.Length, jobs[len(names)-1].Length)
+		}
+	}
+	if want := []string{"A", "B", "A.2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("renamed jobs are %v, want %v", names, want)
+	}
+}
+
+// wrongJob always picks a job that is not ready.
+type wrongJob struct{}
+
+func (wrongJob) Schedule(jobs []Job) (Job, error) {
+	return Job{ID: len(jobs) + 100, Name: "ghost"}, nil
+}
+
+func TestRunReportsSchedulerErrors(t *testing.T) {
+	jobs := []Job{{Name: "A", Length: 1000}}
+	if _, err := NewSimulator().Run(wrongJob{}, jobs); err == nil {
+		t.Error("Run accepted a job that is not ready")
+	}
+	if _, err := NewMultiSimulator(MultiConfig{CPUs: 2}).Run(func() Scheduler { return wrongJob{} }, jobs); err == nil {
+		t.Error("MultiSimulator.Run accepted a job that is not ready")
+	}
+}
+
+func TestPreemptDuringContextSwitch(t *testing.T) {
+	jobs := []Job{
+		{Name: "A", Arrival: 0, Length: 5000},
+		{Name: "B", Arrival: 500, Length: 1000},
+		{Name: "C", Arrival: 1000, Length: 500},
+	}
+	result, err := NewSimulator(WithPreemptOnArrival(), WithContextSwitchCost(1000)).Run(NewSTCFScheduler(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, run := range result.Runs {
+		if run.End <= run.Start {
+			t.Errorf("%s ran from %d to %d", run.Job, run.Start, run.End)
+		}
+	}
+	for idx := 1; idx < len(result.Trace); idx++ {
+		if result.Trace[idx].Time < result.Trace[idx-1].Time {
+			t.Errorf("trace goes back in time from %v to %v", result.Trace[idx-1], result.Trace[idx])
+		}
+	}
+	for idx, job := range result.Jobs {
+		if job.Length != jobs[idx].Length {
+			t.Errorf("%s ran for %d, want %d", job.Name, job.Length, jobs[idx].Length)
+		}
+	}
+}
+
+func TestRejectsNonPositiveQuantum(t *testing.T) {
+	jobs := []Job{{Name: "A", Length: 1000}}
+	for _, quantum := range []int64{0, -1000} {
+		if _, err := NewSimulator(WithQuantum(quantum)).Run(NewRoundRobinScheduler(), jobs); err == nil {
+			t.Errorf("Run accepted quantum %d", quantum)
+		}
+	}
+	if _, err := NewCFSScheduler(CFSConfig{SchedLatency: 48}); err == nil {
+		t.Error("NewCFSScheduler accepted a zero MinGranularity")
+	}
+	if _, err := NewMLFQScheduler(MLFQConfig{Queues: 2, Quantums: []int64{10, 0}}); err == nil {
+		t.Error("NewMLFQScheduler accepted a zero quantum")
+	}
+	if _, err := MakeScheduler("MLFQ", SchedulerOptions{Quantum: -1}); err == nil {
+		t.Error("MakeScheduler accepted a negative MLFQ quantum")
+	}
+}
@@ -0,0 +1,179 @@
+package cpu_schedule
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type EventKind uint8
+
+const (
+	// EventSchedule is recorded when a job gets the CPU.
+	EventSchedule EventKind = iota
+	// EventPreempt is recorded when a runnable job loses the CPU to another.
+	EventPreempt
+	// EventComplete is recorded when a job finishes.
+	EventComplete
+	// EventIdle is recorded when no job is runnable. Duration is how long
+	// the CPU stays idle.
+	EventIdle
+	// EventBlock is recorded when a job starts waiting for I/O. Duration is
+	// how long it stays blocked.
+	EventBlock
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSchedule:
+		return "schedule"
+	case EventPreempt:
+		return "preempt"
+	case EventComplete:
+		return "complete"
+	case EventIdle:
+		return "idle"
+	case EventBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("EventKind(%d)", uint8(k))
+	}
+}
+
+// Event is a single entry of SimulationResult.Trace. Times are in ms.
+type Event struct {
+	Time     int64
+	Kind     EventKind
+	Job      string
+	CPU      int
+	Duration int64
+}
+
+// RenderGantt draws the runs of result as an ASCII Gantt chart, one row per
+// job and one column per unit ms. A column shows '#' if the job ran during it,
+// '-' if it was blocked on I/O, '.' if it was waiting for the CPU and is blank
+// before the job arrives and after it completes.
+func RenderGantt(w io.Writer, result SimulationResult, unit int64) error {
+	if unit <= 0 {
+		return fmt.Errorf("invalid unit %d", unit)
+	}
+
+	columns := int((result.TotalTime + unit - 1) / unit)
+	width := 0
+	for _, job := range result.Jobs {
+		width = max(width, len(job.Name))
+	}
+
+	rows := make(map[string][]byte, len(result.Jobs))
+	for _, job := range result.Jobs {
+		row := []byte(strings.Repeat(" ", columns))
+		for col := range row {
+			t := int64(col) * unit
+			if t >= job.Arrival && (job.Completion == -1 || t < job.Completion) {
+				row[col] = '.'
+			}
+		}
+		rows[job.Name] = row
+	}
+	for _, event := range result.Trace {
+		if event.Kind != EventBlock {
+			continue
+		}
+		row := rows[event.Job]
+		for col := event.Time / unit; col*unit < event.Time+event.Duration && col < int64(len(row)); col++ {
+			row[col] = '-'
+		}
+	}
+	for _, run := range result.Runs {
+		row := rows[run.Job]
+		for col := run.Start / unit; col*unit < run.End && col < int64(len(row)); col++ {
+			row[col] = '#'
+		}
+	}
+
+	for _, job := range result.Jobs {
+		if _, err := fmt.Fprintf(w, "%-*s |%s|\n", width, job.Name, rows[job.Name]); err != nil {
+			return err
+		}
+	}
+
+	axis := []byte(strings.Repeat(" ", columns))
+	for col := 0; col < columns; col += 10 {
+		label := strconv.FormatInt(int64(col)*unit/1000, 10)
+		copy(axis[col:], label)
+	}
+	_, err := fmt.Fprintf(w, "%-*s  %s\n", width, "", axis)
+	return err
+}
+
+// WriteTraceCSV writes result.Trace as CSV with a header row.
+func WriteTraceCSV(w io.Writer, result SimulationResult) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"time", "event", "job", "cpu", "duration"}); err != nil {
+		return err
+	}
+	for _, event := range result.Trace {
+		record := []string{
+			strconv.FormatInt(event.Time, 10),
+			event.Kind.String(),
+			event.Job,
+			strconv.Itoa(event.CPU),
+			strconv.FormatInt(event.Duration, 10),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+type chromeEvent struct {
+	Name     string `json:"name"`
+	Category string `json:"cat,omitempty"`
+	Phase    string `json:"ph"`
+	// Timestamp and Duration are in µs
+	Timestamp int64  `json:"ts"`
+	Duration  int64  `json:"dur,omitempty"`
+	Pid       int    `json:"pid"`
+	Tid       int    `json:"tid"`
+	Scope     string `json:"s,omitempty"`
+}
+
+// WriteChromeTrace writes result in the Chrome trace-event format, which
+// opens in chrome://tracing and Perfetto. Every run becomes a slice on the
+// thread of its CPU and every trace event an instant event.
+func WriteChromeTrace(w io.Writer, result SimulationResult) error {
+	events := make([]chromeEvent, 0, len(result.Runs)+len(result.Trace))
+	for _, run := range result.Runs {
+		events = append(events, chromeEvent{
+			Name:      run.Job,
+			Category:  "run",
+			Phase:     "X",
+			Timestamp: run.Start * 1000,
+			Duration:  (run.End - run.Start) * 1000,
+			Tid:       run.CPU,
+		})
+	}
+	for _, event := range result.Trace {
+		name := event.Kind.String()
+		if event.Job != "" {
+			name = name + " " + event.Job
+		}
+		events = append(events, chromeEvent{
+			Name:      name,
+			Category:  event.Kind.String(),
+			Phase:     "i",
+			Timestamp: event.Time * 1000,
+			Tid:       event.CPU,
+			Scope:     "t",
+		})
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}{events})
+}
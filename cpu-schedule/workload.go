@@ -0,0 +1,122 @@
+package cpu_schedule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Workload files and job lists give times in seconds, like OSTEP's
+// scheduler.py; they are converted to the simulator's ms.
+
+// ParseWorkload reads one job per line in the form
+//
+//	name arrival length [tickets [priority]]
+//
+// Blank lines and lines starting with '#' are skipped.
+func ParseWorkload(reader io.Reader) ([]Job, error) {
+	scanner := bufio.NewScanner(reader)
+	jobs := make([]Job, 0)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		tokens := strings.Fields(line)
+		if len(tokens) < 3 || len(tokens) > 5 {
+			return nil, fmt.Errorf("line %d: expected name, arrival, length, tickets and priority, got %q", lineNum, line)
+		}
+
+		arrival, err := parseSeconds(tokens[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid arrival: %w", lineNum, err)
+		}
+		length, err := parseSeconds(tokens[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid length: %w", lineNum, err)
+		}
+		job := Job{Name: tokens[0], Arrival: arrival, Length: uint64(length)}
+
+		if len(tokens) > 3 {
+			tickets, err := strconv.ParseUint(tokens[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid tickets: %w", lineNum, err)
+			}
+			job.Tickets = tickets
+		}
+		if len(tokens) > 4 {
+			priority, err := strconv.Atoi(tokens[4])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid priority: %w", lineNum, err)
+			}
+			job.Priority = priority
+		}
+
+		jobs = append(jobs, job)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ParseJobList parses a comma separated list of job lengths, like
+// scheduler.py's -l option. All jobs arrive at 0.
+func ParseJobList(list string) ([]Job, error) {
+	jobs := make([]Job, 0)
+	for idx, token := range strings.Split(list, ",") {
+		length, err := parseSeconds(strings.TrimSpace(token))
+		if err != nil {
+			return nil, fmt.Errorf("invalid job length: %w", err)
+		}
+		jobs = append(jobs, Job{Name: fmt.Sprintf("Job %d", idx), Length: uint64(length)})
+	}
+	return jobs, nil
+}
+
+// GenerateWorkload creates count jobs arriving at 0 with random lengths
+// between 1 and maxLength seconds, like scheduler.py's -s, -j and -m options.
+// The same seed always gives the same jobs, though not the ones scheduler.py
+// would pick. It fails if count is negative or maxLength is not positive.
+func GenerateWorkload(seed int64, count int, maxLength int) ([]Job, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid number of jobs %d", count)
+	}
+	if maxLength <= 0 {
+		return nil, fmt.Errorf("invalid max job length %d", maxLength)
+	}
+	r := rand.New(rand.NewSource(seed))
+	jobs := make([]Job, 0, count)
+	for idx := 0; idx < count; idx++ {
+		length := uint64(r.Intn(maxLength)+1) * 1000
+		jobs = append(jobs, Job{Name: fmt.Sprintf("Job %d", idx), Length: length})
+	}
+	return jobs, nil
+}
+
+func parseSeconds(str string) (int64, error) {
+	seconds, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, fmt.Errorf("invalid time %s", str)
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("negative time %s", str)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which no int64 holds
+	ms := math.Round(seconds * 1000)
+	if ms >= math.MaxInt64 {
+		return 0, fmt.Errorf("time %s is too large", str)
+	}
+	return int64(ms), nil
+}
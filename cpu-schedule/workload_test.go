@@ -0,0 +1,40 @@
+package cpu_schedule
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGenerateWorkloadRejectsBadSizes(t *testing.T) {
+	if _, err := GenerateWorkload(0, 3, 0); err == nil {
+		t.Error("GenerateWorkload accepted a max length of 0")
+	}
+	if _, err := GenerateWorkload(0, -1, 10); err == nil {
+		t.Error("GenerateWorkload accepted -1 jobs")
+	}
+	jobs, err := GenerateWorkload(0, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range jobs {
+		if job.Length != 1000 {
+			t.Errorf("%s is %dms long, want 1000", job.Name, job.Length)
+		}
+	}
+}
+
+func TestParseWorkloadRejectsBadTimes(t *testing.T) {
+	for _, line := range []string{"A 0 inf", "A 0 NaN", "A -inf 1", "A 0 1e30", "A 0 -1"} {
+		if jobs, err := ParseWorkload(strings.NewReader(line)); err == nil {
+			t.Errorf("ParseWorkload(%q) = %+v, want an error", line, jobs)
+		}
+	}
+}
+
+func TestValidateJobsRejectsHugeLength(t *testing.T) {
+	jobs := []Job{{Name: "A", Length: math.MaxInt64 + 1}}
+	if err := ValidateJobs(jobs); err == nil {
+		t.Error("ValidateJobs accepted a Length that overflows int64")
+	}
+}
@@ -2,11 +2,59 @@ package vm_freespace
 
 import (
 	"bytes"
+	"fmt"
 	"sort"
+	"strings"
 )
 
+// ListOrder is where a FreeList puts the slots freed into it, as in
+// malloc.py -l.
+type ListOrder uint8
+
+const (
+	// OrderAddrSort keeps the list sorted by address.
+	OrderAddrSort ListOrder = iota
+	// OrderSizeAscending keeps the list sorted from the smallest slot up.
+	OrderSizeAscending
+	// OrderSizeDescending keeps the list sorted from the largest slot down.
+	OrderSizeDescending
+	// OrderInsertFront puts freed slots at the front of the list.
+	OrderInsertFront
+	// OrderInsertBack puts freed slots at the back of the list.
+	OrderInsertBack
+)
+
+var listOrderNames = []string{"ADDRSORT", "SIZESORT+", "SIZESORT-", "INSERT-FRONT", "INSERT-BACK"}
+
+// ParseListOrder returns the ListOrder with the given malloc.py name.
+func ParseListOrder(name string) (ListOrder, error) {
+	for order, orderName := range listOrderNames {
+		if orderName == name {
+			return ListOrder(order), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown list order %s, want one of %s", name, strings.Join(listOrderNames, ", "))
+}
+
+func (o ListOrder) String() string {
+	if int(o) < len(listOrderNames) {
+		return listOrderNames[o]
+	}
+	return fmt.Sprintf("ListOrder(%d)", o)
+}
+
+// FreeListConfig configures a FreeList.
+type FreeListConfig struct {
+	Order ListOrder
+	// Coalesce merges freed slots with the slots next to them in the list
+	// that they border in memory, like malloc.py -C. With the list sorted by
+	// address this merges every pair of adjacent free slots.
+	Coalesce bool
+}
+
 type FreeList struct {
-	slots []Slot
+	slots  []Slot
+	config FreeListConfig
 }
 
 func (l *FreeList) String() string {
@@ -17,11 +65,12 @@ func (l *FreeList) String() string {
 	return out.String()
 }
 
-func NewFreeList(bassAddr int, size int) *FreeList {
+func NewFreeList(bassAddr int, size int, config FreeListConfig) *FreeList {
 	return &FreeList{
 		slots: []Slot{
 			Slot{bassAddr, size},
 		},
+		config: config,
 	}
 }
 
@@ -29,12 +78,61 @@ func (l *FreeList) Size() int {
 	return len(l.slots)
 }
 
+// Add puts a freed slot on the list according to its ListOrder.
 func (l *FreeList) Add(slot Slot) {
-	l.slots = append(l.slots, slot)
-	sort.Slice(l.slots, func(i, j int) bool {
-		return l.slots[i].Addr < l.slots[j].Addr
-	})
+	switch l.config.Order {
+	case OrderInsertFront:
+		l.slots = append([]Slot{slot}, l.slots...)
+	case OrderInsertBack:
+		l.slots = append(l.slots, slot)
+	case OrderSizeAscending:
+		l.slots = append(l.slots, slot)
+		sort.SliceStable(l.slots, func(i, j int) bool {
+			return l.slots[i].Size < l.slots[j].Size
+		})
+	case OrderSizeDescending:
+		l.slots = append(l.slots, slot)
+		sort.SliceStable(l.slots, func(i, j int) bool {
+			return l.slots[i].Size > l.slots[j].Size
+		})
+	default:
+		l.slots = append(l.slots, slot)
+		sort.SliceStable(l.slots, func(i, j int) bool {
+			return l.slots[i].Addr < l.slots[j].Addr
+		})
+	}
+	if l.config.Coalesce {
+		l.coalesce()
+	}
+}
+
+// coalesce merges every slot that starts where the one before it in the list
+// ends into that slot. Like malloc.py it is a single pass over the list.
+func (l *FreeList) coalesce() {
+	merged := l.slots[:1]
+	for _, slot := range l.slots[1:] {
+		last := &merged[len(merged)-1]
+		if last.Addr+last.Size == slot.Addr {
+			last.Size += slot.Size
+		} else {
+			merged = append(merged, slot)
+		}
+	}
+	l.slots = merged
 }
+
+// Replace puts slot where old is in the list, without reordering it. Like
+// malloc.py, the strategies use it for the part of a slot left over after an
+// allocation.
+func (l *FreeList) Replace(old Slot, slot Slot) {
+	for idx, candidate := range l.slots {
+		if candidate.Addr == old.Addr {
+			l.slots[idx] = slot
+			return
+		}
+	}
+}
+
 func (l *FreeList) Remove(victim Slot) {
 	for idx, slot := range l.slots {
 		if slot.Addr == victim.Addr {
@@ -47,3 +145,33 @@ func (l *FreeList) Remove(victim Slot) {
 func (l *FreeList) Slots() []Slot {
 	return l.slots
 }
+
+// Fragmentation describes how scattered the free space is.
+type Fragmentation struct {
+	FreeSlots int
+	TotalFree int
+	// LargestFree is the size of the largest free slot, and so of the largest
+	// allocation that can still succeed.
+	LargestFree int
+	// External is the share of the free space that is not in the largest
+	// slot: 0 when all of it is in one piece, close to 1 when it is spread
+	// over many small slots.
+	External float64
+}
+
+func (l *FreeList) Fragmentation() Fragmentation {
+	frag := Fragmentation{FreeSlots: len(l.slots)}
+	for _, slot := range l.slots {
+		frag.TotalFree += slot.Size
+		frag.LargestFree = max(frag.LargestFree, slot.Size)
+	}
+	if frag.TotalFree > 0 {
+		frag.External = 1 - float64(frag.LargestFree)/float64(frag.TotalFree)
+	}
+	return frag
+}
+
+func (f Fragmentation) String() string {
+	return fmt.Sprintf("Free: %d in %d slots Largest: %d External fragmentation: %.2f%%",
+		f.TotalFree, f.FreeSlots, f.LargestFree, f.External*100)
+}
@@ -0,0 +1,36 @@
+package vm_freespace
+
+import (
+	"testing"
+)
+
+func TestListOrders(t *testing.T) {
+	// frees 1000:30, 1030:10 and 1040:20 with 1065:35 still free
+	const trace = "+30,+10,+20,+5,-0,-1,-2"
+	for _, tt := range []struct {
+		order    ListOrder
+		freeList string
+		addr     int
+		visited  int
+	}{
+		{OrderAddrSort, "[ addr:1000 sz:30 ][ addr:1030 sz:10 ][ addr:1040 sz:20 ][ addr:1065 sz:35 ]", 1000, 1},
+		{OrderSizeAscending, "[ addr:1030 sz:10 ][ addr:1040 sz:20 ][ addr:1000 sz:30 ][ addr:1065 sz:35 ]", 1040, 2},
+		{OrderSizeDescending, "[ addr:1065 sz:35 ][ addr:1000 sz:30 ][ addr:1040 sz:20 ][ addr:1030 sz:10 ]", 1065, 1},
+		{OrderInsertFront, "[ addr:1040 sz:20 ][ addr:1030 sz:10 ][ addr:1000 sz:30 ][ addr:1065 sz:35 ]", 1040, 1},
+		{OrderInsertBack, "[ addr:1065 sz:35 ][ addr:1000 sz:30 ][ addr:1030 sz:10 ][ addr:1040 sz:20 ]", 1065, 1},
+	} {
+		strategy, err := MakeFreeSpaceStrategy("FIRST", 1000, 100, Options{FreeListConfig: FreeListConfig{Order: tt.order}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		runTrace(t, strategy, trace)
+		if got := strategy.FreeList().String(); got != tt.freeList {
+			t.Errorf("%s: free list %s, want %s", tt.order, got, tt.freeList)
+		}
+		res := strategy.Alloc(4, 15)
+		if res.Addr != tt.addr || res.Visited != tt.visited {
+			t.Errorf("%s: Alloc(15) returned %d after searching %d elements, want %d after %d",
+				tt.order, res.Addr, res.Visited, tt.addr, tt.visited)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package vm_freespace
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// DefaultSizeClasses are the size classes of a SegregatedStrategy when
+// Options.SizeClasses is empty. They cover malloc.py's default request sizes.
+var DefaultSizeClasses = []int{2, 4, 8, 16}
+
+// SizeClassStats counts how a size class served its allocations.
+type SizeClassStats struct {
+	Size int
+	// Hits are allocations served straight from the class's own free list.
+	Hits int
+	// Misses are allocations that found the class's list empty and carved a
+	// new block off the general free list.
+	Misses int
+}
+
+// SegregatedStats describes how a SegregatedStrategy served its allocations.
+type SegregatedStats struct {
+	Classes []SizeClassStats
+	// General counts the allocations too large for every size class.
+	General int
+	// Failures counts the allocations no free slot could hold. They are not
+	// counted as misses or general allocations.
+	Failures int
+}
+
+type sizeClass struct {
+	stats    SizeClassStats
+	freeList *FreeList
+}
+
+// SegregatedStrategy keeps a separate free list of equally sized blocks for
+// each size class, like a slab allocator. A request is rounded up to the
+// smallest class that holds it and served from the front of that class's
+// list, so it only looks at one slot. When the list is empty a block is carved
+// off the general free list with best fit, and freed blocks go back to their
+// class rather than to the general list. Requests larger than every class use
+// best fit on the general list.
+type SegregatedStrategy struct {
+	baseStrategy
+	classes []sizeClass
+	// classOf maps the pointers allocated from a size class to its index
+	classOf  map[Pointer]int
+	general  int
+	failures int
+}
+
+func NewSegregatedStrategy(baseAddr int, size int, options Options) *SegregatedStrategy {
+	sizes := make([]int, 0, len(options.SizeClasses))
+	for _, classSize := range options.SizeClasses {
+		if classSize > 0 {
+			sizes = append(sizes, classSize)
+		}
+	}
+	if len(sizes) == 0 {
+		sizes = append(sizes, DefaultSizeClasses...)
+	}
+	// blocks carved for a class must keep the general free list aligned
+	for idx := range sizes {
+		sizes[idx] = alignUp(sizes[idx], options.Alignment)
+	}
+	sort.Ints(sizes)
+
+	s := &SegregatedStrategy{
+		baseStrategy: newBaseStrategy(baseAddr, size, options),
+		classOf:      make(map[Pointer]int),
+	}
+	for _, classSize := range sizes {
+		if len(s.classes) > 0 && s.classes[len(s.classes)-1].stats.Size == classSize {
+			continue
+		}
+		s.classes = append(s.classes, sizeClass{
+			stats: SizeClassStats{Size: classSize},
+			// the most recently freed block is reused first
+			freeList: &FreeList{config: FreeListConfig{Order: OrderInsertFront}},
+		})
+	}
+	return s
+}
+
+// classFor returns the index of the smallest size class that holds block
+// units, or -1 if none does.
+func (s *SegregatedStrategy) classFor(block int) int {
+	for idx, class := range s.classes {
+		if class.stats.Size >= block {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *SegregatedStrategy) Alloc(pointer Pointer, size int) AllocResponse {
+	block := s.blockSize(size)
+	idx := s.classFor(block)
+	if idx == -1 {
+		candidate, found, visited := bestFit(s.freeList, block)
+		if !found {
+			s.failures++
+			return noSlot(block, visited)
+		}
+		s.general++
+		return s.allocate(pointer, candidate, size, block, visited)
+	}
+
+	class := &s.classes[idx]
+	if slots := class.freeList.Slots(); len(slots) > 0 {
+		slot := slots[0]
+		class.freeList.Remove(slot)
+		class.stats.Hits++
+		s.store.Add(pointer, slot)
+		s.classOf[pointer] = idx
+		return AllocResponse{
+			Err:      nil,
+			Visited:  1,
+			Addr:     slot.Addr,
+			Size:     slot.Size,
+			Internal: slot.Size - size - s.options.HeaderSize,
+		}
+	}
+
+	candidate, found, visited := bestFit(s.freeList, class.stats.Size)
+	if !found {
+		s.failures++
+		return noSlot(class.stats.Size, visited)
+	}
+	class.stats.Misses++
+	s.classOf[pointer] = idx
+	return s.allocate(pointer, candidate, size, class.stats.Size, visited)
+}
+
+func (s *SegregatedStrategy) Free(pointer Pointer) error {
+	idx, ok := s.classOf[pointer]
+	if !ok {
+		return s.baseStrategy.Free(pointer)
+	}
+
+	delete(s.classOf, pointer)
+	slot, _ := s.store.Remove(pointer)
+	s.classes[idx].freeList.Add(slot)
+	return nil
+}
+
+// ClassFreeLists returns the free list of every size class, from the
+// smallest class up. FreeList returns the general free list.
+func (s *SegregatedStrategy) ClassFreeLists() []*FreeList {
+	lists := make([]*FreeList, 0, len(s.classes))
+	for _, class := range s.classes {
+		lists = append(lists, class.freeList)
+	}
+	return lists
+}
+
+func (s *SegregatedStrategy) Stats() SegregatedStats {
+	stats := SegregatedStats{General: s.general, Failures: s.failures}
+	for _, class := range s.classes {
+		stats.Classes = append(stats.Classes, class.stats)
+	}
+	return stats
+}
+
+func (s SegregatedStats) String() string {
+	var output bytes.Buffer
+	for _, class := range s.Classes {
+		output.WriteString(fmt.Sprintf("Class %d -- Hits: %d Misses: %d\n", class.Size, class.Hits, class.Misses))
+	}
+	output.WriteString(fmt.Sprintf("General -- Allocations: %d\n", s.General))
+	output.WriteString(fmt.Sprintf("Failures: %d\n", s.Failures))
+	return output.String()
+}
+
+func (s *SegregatedStrategy) String() string {
+	var output bytes.Buffer
+	output.WriteString(s.baseStrategy.String())
+	for _, class := range s.classes {
+		output.WriteString(fmt.Sprintf(",class %d:", class.stats.Size))
+		output.WriteString(class.freeList.String())
+	}
+	return output.String()
+}
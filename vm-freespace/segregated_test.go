@@ -0,0 +1,57 @@
+package vm_freespace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegregatedStats(t *testing.T) {
+	strategy, err := MakeFreeSpaceStrategy("SEGREGATED", 1000, 100, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 misses the 4 class, 4 reuses its freed block, 1 misses the 2 class
+	// and 20 is too large for every class. 100 does not fit at all and
+	// only counts as a failure.
+	responses := runTrace(t, strategy, "+3,-0,+4,+1,+20,+100")
+	checkTrace(t, "SEGREGATED", responses, []int{1000, 1000, 1004, 1006, -1}, []int{1, 1, 1, 1, 1})
+
+	want := SegregatedStats{
+		Classes: []SizeClassStats{
+			{Size: 2, Misses: 1},
+			{Size: 4, Hits: 1, Misses: 1},
+			{Size: 8},
+			{Size: 16},
+		},
+		General:  1,
+		Failures: 1,
+	}
+	if got := strategy.(*SegregatedStrategy).Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("stats %+v, want %+v", got, want)
+	}
+	if got := strategy.FreeList().String(); got != "[ addr:1026 sz:74 ]" {
+		t.Errorf("general free list %s, want [ addr:1026 sz:74 ]", got)
+	}
+}
+
+func TestSegregatedAlignsClasses(t *testing.T) {
+	strategy, err := MakeFreeSpaceStrategy("SEGREGATED", 999, 100, Options{Alignment: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default classes 2, 4, 8 and 16 become 3, 6, 9 and 18
+	responses := runTrace(t, strategy, "+1,+4,+7,+5,+20")
+	for idx, want := range []int{999, 1002, 1008, 1017, 1023} {
+		if res := responses[idx]; res.Addr != want || res.Addr%3 != 0 {
+			t.Errorf("ptr[%d] returned %d, want %d", idx, res.Addr, want)
+		}
+	}
+	want := []int{3, 6, 9, 18}
+	for idx, class := range strategy.(*SegregatedStrategy).Stats().Classes {
+		if class.Size != want[idx] {
+			t.Errorf("class %d has size %d, want %d", idx, class.Size, want[idx])
+		}
+	}
+}
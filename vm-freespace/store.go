@@ -17,10 +17,12 @@ func (s *Store) Add(pointer Pointer, slot Slot) {
 	s.store[pointer] = slot
 }
 
-func (s *Store) Remove(pointer Pointer) Slot {
-	slot := s.store[pointer]
+// Remove returns the slot allocated under pointer and forgets it. It reports
+// false if nothing is allocated under pointer.
+func (s *Store) Remove(pointer Pointer) (Slot, bool) {
+	slot, ok := s.store[pointer]
 	delete(s.store, pointer)
-	return slot
+	return slot, ok
 }
 
 func (s *Store) String() string {
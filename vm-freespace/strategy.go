@@ -9,7 +9,10 @@ type Pointer uint32
 
 type FreeSpaceStrategy interface {
 	Alloc(pointer Pointer, size int) AllocResponse
-	Free(pointer Pointer)
+	// Free returns the block allocated under pointer to the free list. It
+	// fails, leaving the free list alone, if nothing is allocated under
+	// pointer, for instance after a failed Alloc or a double free.
+	Free(pointer Pointer) error
 	FreeList() *FreeList
 }
 
@@ -22,58 +25,109 @@ func (s Slot) String() string {
 	return fmt.Sprintf("[ addr:%d sz:%d ]", s.Addr, s.Size)
 }
 
-type BestStrategy struct {
+type AllocResponse struct {
+	Err     error
+	Visited int
+	// Addr is the start of the block, header included, as in malloc.py.
+	// With an Alignment and an aligned baseAddr it is a multiple of the
+	// alignment; the caller's data starts HeaderSize units after it.
+	Addr int
+	// Size is how much was taken off the free list: the requested size
+	// rounded up to the alignment, plus the header and any leftover too
+	// small to stay free.
+	Size int
+	// Internal is the internal fragmentation of the allocation: the units
+	// in Size that are neither requested nor header.
+	Internal int
+}
+
+// baseStrategy holds what every strategy shares: the free list, the
+// allocated slots and freeing. Strategies only differ in how Alloc searches
+// the free list.
+type baseStrategy struct {
 	freeList *FreeList
 	store    *Store
+	options  Options
 }
 
-type AllocResponse struct {
-	Err     error
-	Visited int
-	Addr    int
+func newBaseStrategy(baseAddr int, size int, options Options) baseStrategy {
+	return baseStrategy{
+		freeList: NewFreeList(baseAddr, size, options.FreeListConfig),
+		store:    NewStore(),
+		options:  options,
+	}
 }
 
-func (s *BestStrategy) Alloc(pointer Pointer, size int) AllocResponse {
-	//ptr[0] = Alloc(3) returned 1000 (searched 1 elements)
-	//Free List [ Size 1 ]: [ addr:1003 sz:97 ]
+// blockSize is how many units a request for size needs from the free list:
+// size plus the header, rounded up to the alignment. Every block is then a
+// multiple of the alignment, so blocks carved from an aligned baseAddr start
+// aligned. malloc.py instead rounds size before adding the header, which
+// leaves blocks unaligned whenever the header is not a multiple of the
+// alignment.
+func (s *baseStrategy) blockSize(size int) int {
+	return alignUp(size+s.options.HeaderSize, s.options.Alignment)
+}
 
-	var candidate Slot
-	visited := 0
-	for _, slot := range s.freeList.Slots() {
-		if slot.Size > size && (candidate.Size == 0 || candidate.Size < slot.Size) {
-			candidate = slot
+// alignUp rounds n up to a multiple of align, if align is positive.
+func alignUp(n int, align int) int {
+	if align > 0 {
+		if left := n % align; left != 0 {
+			n += align - left
 		}
-		visited++
 	}
-	if candidate.Size == 0 {
-		return AllocResponse{Err: fmt.Errorf("no available slot")}
+	return n
+}
+
+// allocate carves a block for size units off the front of candidate, leaves
+// the rest in its place on the free list and records the allocation under
+// pointer. block is at least s.blockSize(size).
+func (s *baseStrategy) allocate(pointer Pointer, candidate Slot, size int, block int, visited int) AllocResponse {
+	if candidate.Size-block < s.options.MinBlockSize {
+		block = candidate.Size
 	}
 
-	s.freeList.Remove(candidate)
-	allocatedSlot := Slot{Addr: candidate.Addr, Size: size}
+	allocatedSlot := Slot{Addr: candidate.Addr, Size: block}
 	s.store.Add(pointer, allocatedSlot)
-	if candidate.Size > size {
-		remainingSlot := Slot{Addr: candidate.Addr + size, Size: candidate.Size - size}
-		s.freeList.Add(remainingSlot)
+	if candidate.Size > block {
+		remainingSlot := Slot{Addr: candidate.Addr + block, Size: candidate.Size - block}
+		s.freeList.Replace(candidate, remainingSlot)
+	} else {
+		s.freeList.Remove(candidate)
+	}
+
+	return AllocResponse{
+		Err:      nil,
+		Visited:  visited,
+		Addr:     allocatedSlot.Addr,
+		Size:     block,
+		Internal: block - size - s.options.HeaderSize,
 	}
+}
 
+// noSlot is the response when no free slot can hold size units. Like
+// malloc.py, the address is -1.
+func noSlot(size int, visited int) AllocResponse {
 	return AllocResponse{
-		Err:     nil,
+		Err:     fmt.Errorf("no available slot for %d", size),
 		Visited: visited,
-		Addr:    allocatedSlot.Addr,
+		Addr:    -1,
 	}
 }
 
-func (s *BestStrategy) Free(pointer Pointer) {
-	slot := s.store.Remove(pointer)
+func (s *baseStrategy) Free(pointer Pointer) error {
+	slot, ok := s.store.Remove(pointer)
+	if !ok {
+		return fmt.Errorf("pointer %d is not allocated", pointer)
+	}
 	s.freeList.Add(slot)
+	return nil
 }
 
-func (s *BestStrategy) FreeList() *FreeList {
+func (s *baseStrategy) FreeList() *FreeList {
 	return s.freeList
 }
 
-func (s *BestStrategy) String() string {
+func (s *baseStrategy) String() string {
 	var output bytes.Buffer
 	output.WriteString("freelist:")
 	output.WriteString(s.freeList.String())
@@ -83,13 +137,160 @@ func (s *BestStrategy) String() string {
 	return output.String()
 }
 
-func MakeFreeSpaceStrategy(strategyName string, baseAddr int, size int) (FreeSpaceStrategy, error) {
+// BestStrategy searches the whole free list for the smallest slot that fits.
+type BestStrategy struct {
+	baseStrategy
+}
+
+func (s *BestStrategy) Alloc(pointer Pointer, size int) AllocResponse {
+	//ptr[0] = Alloc(3) returned 1000 (searched 1 elements)
+	//Free List [ Size 1 ]: [ addr:1003 sz:97 ]
+
+	block := s.blockSize(size)
+	candidate, found, visited := bestFit(s.freeList, block)
+	if !found {
+		return noSlot(block, visited)
+	}
+
+	return s.allocate(pointer, candidate, size, block, visited)
+}
+
+// bestFit returns the smallest slot on freeList that can hold block units,
+// the first of them if several are as small, and how many slots it looked at.
+func bestFit(freeList *FreeList, block int) (candidate Slot, found bool, visited int) {
+	for _, slot := range freeList.Slots() {
+		if slot.Size >= block && (!found || slot.Size < candidate.Size) {
+			candidate = slot
+			found = true
+		}
+		visited++
+	}
+	return candidate, found, visited
+}
+
+// WorstStrategy searches the whole free list for the largest slot, so the
+// rest of it stays big enough to be useful.
+type WorstStrategy struct {
+	baseStrategy
+}
+
+func (s *WorstStrategy) Alloc(pointer Pointer, size int) AllocResponse {
+	block := s.blockSize(size)
+	var candidate Slot
+	found := false
+	visited := 0
+	for _, slot := range s.freeList.Slots() {
+		if slot.Size >= block && (!found || slot.Size > candidate.Size) {
+			candidate = slot
+			found = true
+		}
+		visited++
+	}
+	if !found {
+		return noSlot(block, visited)
+	}
+
+	return s.allocate(pointer, candidate, size, block, visited)
+}
+
+// FirstStrategy takes the first slot that fits and stops searching there.
+type FirstStrategy struct {
+	baseStrategy
+}
+
+func (s *FirstStrategy) Alloc(pointer Pointer, size int) AllocResponse {
+	block := s.blockSize(size)
+	visited := 0
+	for _, slot := range s.freeList.Slots() {
+		visited++
+		if slot.Size >= block {
+			return s.allocate(pointer, slot, size, block, visited)
+		}
+	}
+	return noSlot(block, visited)
+}
+
+// NextStrategy is FirstStrategy with a roving pointer: every search starts
+// where the last one succeeded and wraps around the end of the free list, so
+// small leftovers do not pile up at its front.
+type NextStrategy struct {
+	baseStrategy
+	// next is an address in the free slot where the next search starts. It
+	// is an address rather than a position so that slots freed in front of
+	// it do not move it.
+	next int
+}
+
+// start returns the position in slots of the free slot that holds s.next,
+// or 0 if there is none.
+func (s *NextStrategy) start(slots []Slot) int {
+	for idx, slot := range slots {
+		if slot.Addr <= s.next && s.next < slot.Addr+slot.Size {
+			return idx
+		}
+	}
+	return 0
+}
+
+func (s *NextStrategy) Alloc(pointer Pointer, size int) AllocResponse {
+	block := s.blockSize(size)
+	slots := s.freeList.Slots()
+	start := s.start(slots)
+	visited := 0
+	for offset := range slots {
+		idx := (start + offset) % len(slots)
+		slot := slots[idx]
+		visited++
+		if slot.Size >= block {
+			res := s.allocate(pointer, slot, size, block, visited)
+			// whatever is left of the slot takes its place in the list, and
+			// if nothing is left the following slot does
+			slots = s.freeList.Slots()
+			if len(slots) > 0 {
+				s.next = slots[idx%len(slots)].Addr
+			}
+			return res
+		}
+	}
+	return noSlot(block, visited)
+}
+
+// Options configures the strategies made by MakeFreeSpaceStrategy. The zero
+// value matches malloc.py's defaults.
+type Options struct {
+	FreeListConfig
+	// HeaderSize is added to every allocation for the allocator's
+	// bookkeeping, like malloc.py -H.
+	HeaderSize int
+	// Alignment rounds every block, header included, up to a multiple of
+	// it, like malloc.py -a, so that blocks start aligned. Zero or less
+	// disables it.
+	Alignment int
+	// MinBlockSize is the smallest slot left on the free list after an
+	// allocation. A smaller leftover is handed out with the allocation and
+	// counts as internal fragmentation. malloc.py has no such limit.
+	MinBlockSize int
+	// SizeClasses are the block sizes, header included, a
+	// SegregatedStrategy keeps free lists for. Empty means
+	// DefaultSizeClasses. They are rounded up to the Alignment.
+	SizeClasses []int
+}
+
+// MakeFreeSpaceStrategy returns the strategy malloc.py calls strategyName,
+// managing size units starting at baseAddr: BEST, WORST, FIRST or NEXT, or
+// SEGREGATED for a SegregatedStrategy.
+func MakeFreeSpaceStrategy(strategyName string, baseAddr int, size int, options Options) (FreeSpaceStrategy, error) {
 	switch strategyName {
 	case "BEST":
-		return &BestStrategy{
-			freeList: NewFreeList(baseAddr, size),
-			store:    NewStore(),
-		}, nil
+		return &BestStrategy{newBaseStrategy(baseAddr, size, options)}, nil
+	case "WORST":
+		return &WorstStrategy{newBaseStrategy(baseAddr, size, options)}, nil
+	case "FIRST":
+		return &FirstStrategy{newBaseStrategy(baseAddr, size, options)}, nil
+	case "NEXT":
+		return &NextStrategy{baseStrategy: newBaseStrategy(baseAddr, size, options)}, nil
+	case "SEGREGATED":
+		return NewSegregatedStrategy(baseAddr, size, options), nil
 
 	default:
 		return nil, fmt.Errorf("unknown strategy %s", strategyName)
@@ -0,0 +1,183 @@
+package vm_freespace
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNextFitRovesPastFreedSlots(t *testing.T) {
+	strategy, err := MakeFreeSpaceStrategy("NEXT", 1000, 100, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pointer := Pointer(0); pointer < 3; pointer++ {
+		res := strategy.Alloc(pointer, 10)
+		if want := 1000 + 10*int(pointer); res.Err != nil || res.Addr != want || res.Visited != 1 {
+			t.Fatalf("Alloc(10) = %+v, want address %d after 1 slot", res, want)
+		}
+	}
+	strategy.Free(0)
+
+	// the search starts at 1030, not at the freed slot in front of it
+	res := strategy.Alloc(3, 5)
+	if res.Addr != 1030 || res.Visited != 1 {
+		t.Errorf("Alloc(5) = %+v, want address 1030 after 1 slot", res)
+	}
+	// a request the rest of the space cannot hold wraps around to 1000
+	res = strategy.Alloc(4, 66)
+	if res.Err == nil {
+		t.Fatalf("Alloc(66) = %+v, want a failure", res)
+	}
+	if res.Visited != 2 {
+		t.Errorf("Alloc(66) visited %d slots, want 2", res.Visited)
+	}
+	res = strategy.Alloc(5, 10)
+	if res.Addr != 1035 || res.Visited != 1 {
+		t.Errorf("Alloc(10) = %+v, want address 1035 after 1 slot", res)
+	}
+	strategy.Free(5)
+	res = strategy.Alloc(6, 10)
+	if res.Addr != 1045 || res.Visited != 1 {
+		t.Errorf("Alloc(10) = %+v, want address 1045 after 1 slot", res)
+	}
+}
+
+func TestFreeUnknownPointer(t *testing.T) {
+	for _, name := range []string{"BEST", "WORST", "FIRST", "NEXT", "SEGREGATED"} {
+		strategy, err := MakeFreeSpaceStrategy(name, 1000, 10, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res := strategy.Alloc(0, 20); res.Err == nil {
+			t.Fatalf("%s: Alloc(20) = %+v, want a failure", name, res)
+		}
+		if err := strategy.Free(0); err == nil {
+			t.Errorf("%s: freeing a failed allocation succeeded", name)
+		}
+		strategy.Alloc(1, 2)
+		if err := strategy.Free(1); err != nil {
+			t.Errorf("%s: Free(1) = %v", name, err)
+		}
+		if err := strategy.Free(1); err == nil {
+			t.Errorf("%s: double free succeeded", name)
+		}
+
+		// no bogus slots were added, so the free list only holds real ones
+		for _, slot := range strategy.FreeList().Slots() {
+			if slot.Size == 0 {
+				t.Errorf("%s: free list %s holds an empty slot", name, strategy.FreeList())
+			}
+		}
+	}
+}
+
+func TestAlignedBlocks(t *testing.T) {
+	strategy, err := MakeFreeSpaceStrategy("FIRST", 1000, 100, Options{HeaderSize: 1, Alignment: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pointer, want := range []AllocResponse{
+		{Visited: 1, Addr: 1000, Size: 4, Internal: 0},
+		{Visited: 1, Addr: 1004, Size: 8, Internal: 3},
+		{Visited: 1, Addr: 1012, Size: 12, Internal: 2},
+	} {
+		size := []int{3, 4, 9}[pointer]
+		if res := strategy.Alloc(Pointer(pointer), size); res != want {
+			t.Errorf("Alloc(%d) = %+v, want %+v", size, res, want)
+		}
+	}
+}
+
+func TestInternalFragmentation(t *testing.T) {
+	for _, tt := range []struct {
+		options Options
+		size    int
+		want    AllocResponse
+	}{
+		{Options{}, 5, AllocResponse{Visited: 1, Addr: 1000, Size: 5}},
+		{Options{HeaderSize: 2}, 5, AllocResponse{Visited: 1, Addr: 1000, Size: 7}},
+		{Options{Alignment: 4}, 5, AllocResponse{Visited: 1, Addr: 1000, Size: 8, Internal: 3}},
+		{Options{HeaderSize: 2, Alignment: 4}, 5, AllocResponse{Visited: 1, Addr: 1000, Size: 8, Internal: 1}},
+		// the 3 units left over are too small to stay free
+		{Options{MinBlockSize: 4}, 17, AllocResponse{Visited: 1, Addr: 1000, Size: 20, Internal: 3}},
+	} {
+		strategy, err := MakeFreeSpaceStrategy("FIRST", 1000, 20, tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := strategy.Alloc(0, tt.size); res != tt.want {
+			t.Errorf("%+v: Alloc(%d) = %+v, want %+v", tt.options, tt.size, res, tt.want)
+		}
+	}
+}
+
+// runTrace runs a malloc.py -A style list of operations: "+n" allocates n
+// units and "-p" frees the pointer returned by the p-th allocation. It returns
+// the response of every allocation.
+func runTrace(t *testing.T, strategy FreeSpaceStrategy, trace string) []AllocResponse {
+	t.Helper()
+	responses := make([]AllocResponse, 0)
+	for _, op := range strings.Split(trace, ",") {
+		n, err := strconv.Atoi(op[1:])
+		if err != nil {
+			t.Fatalf("bad operation %q", op)
+		}
+		if op[0] == '+' {
+			responses = append(responses, strategy.Alloc(Pointer(len(responses)), n))
+		} else {
+			strategy.Free(Pointer(n))
+		}
+	}
+	return responses
+}
+
+func checkTrace(t *testing.T, name string, responses []AllocResponse, addrs []int, visited []int) {
+	t.Helper()
+	for idx, res := range responses {
+		if res.Addr != addrs[idx] || res.Visited != visited[idx] {
+			t.Errorf("%s: ptr[%d] returned %d after searching %d elements, want %d after %d",
+				name, idx, res.Addr, res.Visited, addrs[idx], visited[idx])
+		}
+	}
+}
+
+// seed0Trace is the list of operations of malloc.py -S 100 -b 1000 -s 0 -n 10.
+const seed0Trace = "+3,-0,+5,-1,+8,-2,+8,-3,+2,+7"
+
+func TestMallocTraces(t *testing.T) {
+	for _, tt := range []struct {
+		strategy string
+		options  Options
+		addrs    []int
+		visited  []int
+		freeList string
+	}{
+		// the outputs of malloc.py -c with -p BEST, FIRST and WORST
+		{"BEST", Options{},
+			[]int{1000, 1003, 1008, 1008, 1000, 1008}, []int{1, 2, 3, 4, 4, 4},
+			"[ addr:1002 sz:1 ][ addr:1003 sz:5 ][ addr:1015 sz:1 ][ addr:1016 sz:84 ]"},
+		{"FIRST", Options{},
+			[]int{1000, 1003, 1008, 1008, 1000, 1008}, []int{1, 2, 3, 3, 1, 3},
+			"[ addr:1002 sz:1 ][ addr:1003 sz:5 ][ addr:1015 sz:1 ][ addr:1016 sz:84 ]"},
+		{"WORST", Options{},
+			[]int{1000, 1003, 1008, 1016, 1024, 1026}, []int{1, 2, 3, 4, 5, 5},
+			"[ addr:1000 sz:3 ][ addr:1003 sz:5 ][ addr:1008 sz:8 ][ addr:1016 sz:8 ][ addr:1033 sz:67 ]"},
+		// malloc.py -C: every free merges back into one slot
+		{"BEST", Options{FreeListConfig: FreeListConfig{Coalesce: true}},
+			[]int{1000, 1000, 1000, 1000, 1000, 1002}, []int{1, 1, 1, 1, 1, 1},
+			"[ addr:1009 sz:91 ]"},
+	} {
+		strategy, err := MakeFreeSpaceStrategy(tt.strategy, 1000, 100, tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTrace(t, tt.strategy, runTrace(t, strategy, seed0Trace), tt.addrs, tt.visited)
+		if got := strategy.FreeList().String(); got != tt.freeList {
+			t.Errorf("%s: free list %s, want %s", tt.strategy, got, tt.freeList)
+		}
+	}
+}